@@ -0,0 +1,101 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"strings"
+
+	"github.com/swdunlop/zugzug-go/zug"
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/parser"
+)
+
+// bindComplete binds the hidden "__complete" command, which prints candidates for the last of its arguments, one per
+// line, for use by shell completion scripts.  The last argument may be empty to list every candidate.  If the last
+// argument names a group of commands, like "list", its subcommands are the candidates.
+func (cfg *config) bindComplete() {
+	cfg.bindTask(zug.Alias(`__complete`, zug.New(cfg.provideCompletions)), parser.Custom(), nil, ``)
+	cfg.topics = cfg.topics[:len(cfg.topics)-1] // hidden from help.
+}
+
+func (cfg *config) provideCompletions(ctx context.Context) error {
+	for _, candidate := range cfg.complete(parser.Args(ctx)) {
+		if err := console.Print(ctx, candidate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// complete returns candidates for the last of args, which may be a partial command name or flag.
+func (cfg *config) complete(args []string) []string {
+	partial := ``
+	if len(args) > 0 {
+		partial, args = args[len(args)-1], args[:len(args)-1]
+	}
+	var flags Parser
+	if cfg.globals != nil {
+		flags = cfg.globals
+		for len(args) > 0 && strings.HasPrefix(args[0], `-`) {
+			args = args[1:] // skip global flags.
+		}
+	}
+	for len(args) > 0 {
+		task := cfg.match(args...)
+		if task == nil {
+			break
+		}
+		args = args[len(task.name):]
+		if task.parser != nil {
+			if strings.HasPrefix(partial, `-`) {
+				return completeFlags(task.parser, partial)
+			}
+			return nil
+		}
+		flags = nil
+	}
+	if strings.HasPrefix(partial, `-`) {
+		return completeFlags(flags, partial)
+	}
+	if group := append(append([]string{}, args...), partial); partial != `` && len(cfg.subcommands(group...)) > 0 {
+		args, partial = group, `` // the last word names a group, like "list", so offer its subcommands.
+	}
+
+	var candidates []string
+	seen := make(map[string]struct{})
+	for _, topic := range cfg.topics {
+		name := strings.Split(topic, ` `)
+		if len(name) <= len(args) || strings.Join(name[:len(args)], ` `) != strings.Join(args, ` `) {
+			continue
+		}
+		word := name[len(args)]
+		if _, ok := seen[word]; ok || !strings.HasPrefix(word, partial) {
+			continue
+		}
+		seen[word] = struct{}{}
+		candidates = append(candidates, word)
+	}
+	return candidates
+}
+
+// completeFlags returns the flags of p that start with partial, if p lists its flags.
+func completeFlags(p Parser, partial string) []string {
+	lister, ok := p.(FlagLister)
+	if !ok {
+		return nil
+	}
+	var candidates []string
+	for _, flag := range lister.Flags() {
+		for _, candidate := range []string{`--` + flag.Name, `-` + flag.Shorthand} {
+			if candidate != `-` && strings.HasPrefix(candidate, partial) {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+	"github.com/swdunlop/zugzug-go/zug/parser"
+)
+
+func TestComplete(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{`list`}, []string{`go`, `go-sources`}},
+		{[]string{`list`, ``}, []string{`go`, `go-sources`}},
+		{[]string{`list`, `go-`}, []string{`go-sources`}},
+		{[]string{`bu`}, []string{`build`}},
+		{[]string{``}, []string{`help`, `build`, `list`, `lint`}},
+		{[]string{`--o`}, []string{`--output`}},
+		{[]string{`lint`, `--f`}, []string{`--fix`}},
+		{[]string{`nothing`}, []string{}},
+	} {
+		var ran []string
+		z := mustNew(t, listTasks(&ran), Globals(parser.String(new(string), `output`, `o`, `output file`)),
+			Tasks{{Name: `lint`, Fn: listTasks(&ran)[0].Fn, Parser: parser.New(parser.Bool(new(bool), `fix`, `f`, `fixes problems`))}},
+		)
+		ctx, stdout, _ := consoletest.New()
+		if err := z.Run(ctx, append([]string{`__complete`}, tc.args...)...); err != nil {
+			t.Fatalf(`%q: %v`, tc.args, err)
+		}
+		got := strings.Fields(stdout.String())
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf(`%q: got %q, want %q`, tc.args, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ConfigFile reads values for settings from a JSON object in path, or a TOML document if path ends with ".toml", which
+// is ignored if it does not exist.  The environment takes precedence over the file, and the file takes precedence over
+// the defaults.  Nested objects provide dotted names, so {"db": {"host": "localhost"}} provides "db.host", and arrays
+// provide comma separated values, like the environment, so ["a", "b"] provides "a,b".  Later files take precedence
+// over earlier ones.  A relative path is resolved against the console directory.
+func ConfigFile(path string) Option {
+	return fnOption(func(cfg *config) { cfg.configFiles = append(cfg.configFiles, path) })
+}
+
+// readConfigFile adds the values in the JSON object or TOML document in path to table.
+func readConfigFile(table map[string]string, path string) error {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	}
+	var obj map[string]any
+	if strings.EqualFold(filepath.Ext(path), `.toml`) {
+		err = toml.Unmarshal(data, &obj)
+	} else {
+		err = json.Unmarshal(data, &obj)
+	}
+	if err != nil {
+		return fmt.Errorf(`%w in %q`, err, path)
+	}
+	flattenConfig(table, ``, obj)
+	return nil
+}
+
+func flattenConfig(table map[string]string, prefix string, obj map[string]any) {
+	for key, value := range obj {
+		switch value := value.(type) {
+		case nil:
+		case map[string]any:
+			flattenConfig(table, prefix+key+`.`, value)
+		default:
+			table[prefix+key] = configValue(value)
+		}
+	}
+}
+
+// configValue formats a value from a config file the way it would appear in the environment.
+func configValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case time.Time:
+		return value.Format(time.RFC3339Nano) // TOML has dates and times, which JSON would quote.
+	case []any:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = configValue(item)
+		}
+		return strings.Join(items, `,`)
+	default:
+		js, _ := json.Marshal(value)
+		return string(js)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestConfigFile(t *testing.T) {
+	for name, content := range map[string]string{
+		`config.json`: `{"name": "file", "port": 1, "tags": ["a", "b"], "db": {"host": "db.local"}}`,
+		`config.toml`: "name = \"file\"\nport = 1\ntags = [\"a\", \"b\"]\n\n[db]\nhost = \"db.local\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			var (
+				settingName, host string
+				port              int
+				tags              []string
+			)
+			z := mustNew(t, ConfigFile(path), Tasks{{
+				Name: `show`,
+				Fn:   func(ctx context.Context) error { return nil },
+				Settings: Settings{
+					{Var: &settingName, Name: `name`},
+					{Var: &port, Name: `port`},
+					{Var: &tags, Name: `tags`},
+					{Var: &host, Name: `db.host`},
+				},
+			}})
+			ctx, _, _ := consoletest.New(console.FullEnv([]string{`port=2`}))
+			if err := z.Run(ctx, `show`); err != nil {
+				t.Fatal(err)
+			}
+			if settingName != `file` {
+				t.Errorf(`got name %q, want the value from the file`, settingName)
+			}
+			if port != 2 {
+				t.Errorf(`got port %v, want the value from the environment`, port)
+			}
+			if want := []string{`a`, `b`}; !reflect.DeepEqual(tags, want) {
+				t.Errorf(`got tags %q, want %q`, tags, want)
+			}
+			if host != `db.local` {
+				t.Errorf(`got db.host %q, want the nested value from the file`, host)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestListGoSources(t *testing.T) {
+	var ran [][]string
+	ctx, stdout, _ := consoletest.New(console.ExecHook(func(cmd *exec.Cmd) error {
+		ran = append(ran, cmd.Args)
+		_, err := io.WriteString(cmd.Stdout, "./example.go\n")
+		return err
+	}))
+	if err := ListGoSources(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{`find`, `.`, `-name`, `*.go`}}; !reflect.DeepEqual(ran, want) {
+		t.Errorf(`ran %q, want %q`, ran, want)
+	}
+	if stdout.String() != "./example.go\n" {
+		t.Errorf(`got %q, want the canned output`, stdout.String())
+	}
+
+	errMissing := errors.New(`find is not installed`)
+	ctx, _, _ = consoletest.New(console.ExecHook(func(cmd *exec.Cmd) error { return errMissing }))
+	if err := ListGoSources(ctx); !errors.Is(err, errMissing) {
+		t.Errorf(`got %v, want the canned error`, err)
+	}
+}
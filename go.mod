@@ -1,5 +1,12 @@
 module github.com/swdunlop/zugzug-go
 
-go 1.19
+go 1.21
 
-require github.com/spf13/pflag v1.0.5
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/spf13/pflag v1.0.5
+	golang.org/x/term v0.22.0
+)
+
+require golang.org/x/sys v0.22.0 // indirect
@@ -0,0 +1,70 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/swdunlop/zugzug-go/zug"
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/parser"
+)
+
+// ListTasks adds a "list-tasks" command that writes a JSON array describing each command, its flags and its settings
+// to stdout for use by editors and scripts.
+func ListTasks() Option {
+	return fnOption(func(cfg *config) {
+		cfg.bindTask(zug.Alias(`list-tasks`, zug.New(cfg.listTasks)), nil, nil, `lists commands as JSON`)
+	})
+}
+
+// FlagLister describes an interface that may be implemented by a parser to describe its flags.  This is implemented
+// by zug/parser.
+type FlagLister interface {
+	// Flags returns a description of each flag the parser accepts.
+	Flags() []parser.Flag
+}
+
+type taskListing struct {
+	Name     string           `json:"name"`
+	Use      string           `json:"use"`
+	Flags    []parser.Flag    `json:"flags"`
+	Settings []settingListing `json:"settings"`
+}
+
+type settingListing struct {
+	Name  string `json:"name"`
+	Use   string `json:"use"`
+	Value string `json:"value"`
+}
+
+func (cfg *config) listTasks(ctx context.Context) error {
+	listing := make([]taskListing, 0, len(cfg.topics))
+	for _, topic := range cfg.topics {
+		task := cfg.matchStr(topic)
+		if task == nil {
+			continue
+		}
+		item := taskListing{
+			Name:     topic,
+			Use:      task.use,
+			Flags:    []parser.Flag{},
+			Settings: make([]settingListing, 0, len(task.settings)),
+		}
+		if lister, ok := task.parser.(FlagLister); ok {
+			item.Flags = append(item.Flags, lister.Flags()...)
+		}
+		for _, it := range task.settings {
+			item.Settings = append(item.Settings, settingListing{it.label(), it.Use, it.value()})
+		}
+		listing = append(listing, item)
+	}
+	enc := json.NewEncoder(console.From(ctx).Stdout())
+	enc.SetIndent(``, `  `)
+	return enc.Encode(listing)
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+	"github.com/swdunlop/zugzug-go/zug/parser"
+)
+
+func TestListTasks(t *testing.T) {
+	port := 8080
+	z := mustNew(t, ListTasks(), Tasks{
+		{
+			Name:     `serve`,
+			Fn:       func(ctx context.Context) error { return nil },
+			Use:      `serves it`,
+			Parser:   parser.New(parser.Bool(new(bool), `open`, `o`, `opens a browser`)),
+			Settings: Settings{{Var: &port, Name: `PORT`, Use: `port to listen on`}},
+		},
+		{Name: `exec`, Fn: func(ctx context.Context) error { return nil }, Parser: parser.Custom()},
+	})
+	ctx, stdout, _ := consoletest.New()
+	if err := z.Run(ctx, `list-tasks`); err != nil {
+		t.Fatal(err)
+	}
+	var listing []taskListing
+	if err := json.Unmarshal(stdout.Bytes(), &listing); err != nil {
+		t.Fatalf(`%v in %q`, err, stdout.String())
+	}
+	tasks := make(map[string]taskListing)
+	for _, task := range listing {
+		tasks[task.Name] = task
+	}
+
+	serve, ok := tasks[`serve`]
+	switch {
+	case !ok:
+		t.Fatalf(`serve is missing from %+v`, listing)
+	case serve.Use != `serves it`:
+		t.Errorf(`got use %q for serve`, serve.Use)
+	case len(serve.Flags) != 1 || serve.Flags[0].Name != `open` || serve.Flags[0].Shorthand != `o`:
+		t.Errorf(`got flags %+v for serve`, serve.Flags)
+	}
+	if want := []settingListing{{`PORT`, `port to listen on`, `8080`}}; !reflect.DeepEqual(serve.Settings, want) {
+		t.Errorf(`got settings %+v for serve, want %+v`, serve.Settings, want)
+	}
+
+	exec, ok := tasks[`exec`]
+	switch {
+	case !ok:
+		t.Fatalf(`exec is missing from %+v`, listing)
+	case exec.Flags == nil || len(exec.Flags) != 0:
+		t.Errorf(`got flags %#v for a parser that cannot list them, want an empty array`, exec.Flags)
+	}
+	if _, ok := tasks[`list-tasks`]; !ok {
+		t.Errorf(`list-tasks is missing from %+v`, listing)
+	}
+}
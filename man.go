@@ -0,0 +1,101 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ManPage renders the commands, global flags and settings configured by options as a manual page in troff format,
+// such as for a "man" task or a packaging script that shares the options given to Main.
+func ManPage(options ...Option) ([]byte, error) {
+	cfg := newConfig(options...)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	return cfg.manPage()
+}
+
+func (cfg *config) manPage() ([]byte, error) {
+	argv0 := cfg.baseCommandName()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, ".TH %s 1\n", manEscape(strings.ToUpper(argv0)))
+	buf.WriteString(".SH NAME\n")
+	fmt.Fprintf(&buf, "%s \\- runs tasks\n", manEscape(argv0))
+	buf.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&buf, ".B %s\n", manEscape(argv0))
+	if cfg.globals != nil {
+		buf.WriteString("[\\fIflag\\fR...]\n")
+	}
+	buf.WriteString("\\fIcommand\\fR [\\fIargument\\fR...]\n")
+
+	if lister, ok := cfg.globals.(FlagLister); ok {
+		if flags := lister.Flags(); len(flags) > 0 {
+			buf.WriteString(".SH OPTIONS\n")
+			for _, flag := range flags {
+				buf.WriteString(".TP\n")
+				if flag.Shorthand != `` {
+					fmt.Fprintf(&buf, ".BR \\-%s \", \" \\-\\-%s\n", manEscape(flag.Shorthand), manEscape(flag.Name))
+				} else {
+					fmt.Fprintf(&buf, ".B \\-\\-%s\n", manEscape(flag.Name))
+				}
+				manText(&buf, flag.Usage)
+			}
+		}
+	}
+
+	buf.WriteString(".SH COMMANDS\n")
+	for _, topic := range cfg.topics {
+		task := cfg.matchStr(topic)
+		if task == nil || topic == `help` {
+			continue
+		}
+		buf.WriteString(".TP\n")
+		fmt.Fprintf(&buf, ".B %s %s\n", manEscape(argv0), manEscape(topic))
+		manText(&buf, task.use)
+		if helper, ok := task.parser.(Helper); ok {
+			buf.WriteString(".IP\n.nf\n")
+			manText(&buf, helper.Help(argv0+` `+topic))
+			buf.WriteString(".fi\n")
+		}
+	}
+
+	if settings := cfg.allSettings(); len(settings) > 0 {
+		buf.WriteString(".SH ENVIRONMENT\n")
+		for _, it := range settings {
+			buf.WriteString(".TP\n")
+			fmt.Fprintf(&buf, ".B %s\n", manEscape(it.label()))
+			if value := it.value(); value != `` {
+				manText(&buf, fmt.Sprintf(`%s (default: %q)`, it.Use, value))
+			} else {
+				manText(&buf, it.Use)
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// manText writes each line of text to buf, escaped so troff does not treat it as a request.
+func manText(buf *bytes.Buffer, text string) {
+	if text == `` {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line = manEscape(line)
+		if strings.HasPrefix(line, `.`) || strings.HasPrefix(line, `'`) {
+			buf.WriteString(`\&`)
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+}
+
+func manEscape(text string) string {
+	return strings.NewReplacer(`\`, `\e`, `-`, `\-`).Replace(text)
+}
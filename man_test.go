@@ -0,0 +1,53 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/parser"
+)
+
+func TestManPage(t *testing.T) {
+	port := 8080
+	var ran []string
+	page, err := ManPage(Name(`test`), listTasks(&ran), Tasks{{
+		Name:     `serve`,
+		Fn:       func(ctx context.Context) error { return nil },
+		Use:      `serves it`,
+		Parser:   parser.New(parser.Bool(new(bool), `open`, `o`, `opens a browser`)),
+		Settings: Settings{{Var: &port, Name: `PORT`, Use: `port to listen on`}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		".TH TEST 1\n",
+		".SH NAME\n",
+		".SH SYNOPSIS\n",
+		".SH COMMANDS\n",
+		".B test build\nbuilds it\n",
+		".B test list go\\-sources\n",
+		".B test serve\nserves it\n",
+		`\-\-open`,
+		".SH ENVIRONMENT\n",
+		".B PORT\nport to listen on (default: \"8080\")\n",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("man page does not contain %q:\n%s", want, page)
+		}
+	}
+	if len(ran) > 0 {
+		t.Errorf(`rendering the man page ran %q`, ran)
+	}
+
+	if _, err := ManPage(Name(`test`), listTasks(&ran), listTasks(&ran)); err == nil {
+		t.Error(`expected an error for duplicate commands`)
+	}
+}
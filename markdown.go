@@ -0,0 +1,75 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GenerateMarkdown writes a Markdown document for the commands configured by options, with a table of commands followed
+// by a section for each command explaining its flags and settings.
+func GenerateMarkdown(w io.Writer, options ...Option) error {
+	cfg := newConfig(options...)
+	if cfg.err != nil {
+		return cfg.err
+	}
+	return cfg.generateMarkdown(w)
+}
+
+func (cfg *config) generateMarkdown(w io.Writer) error {
+	argv0 := cfg.baseCommandName()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n\n", argv0)
+	bw.WriteString("| Command | Description |\n")
+	bw.WriteString("| --- | --- |\n")
+	for _, topic := range cfg.topics {
+		task := cfg.matchStr(topic)
+		if task == nil || topic == `help` {
+			continue
+		}
+		usage, _, _ := strings.Cut(task.use, "\n")
+		usage = strings.TrimSuffix(usage, "\r")
+		fmt.Fprintf(bw, "| `%s %s` | %s |\n", argv0, topic, strings.ReplaceAll(usage, `|`, `\|`))
+	}
+
+	if len(cfg.globalSettings) > 0 {
+		bw.WriteString("\n## Settings\n\n")
+		markdownSettings(bw, cfg.globalSettings)
+	}
+
+	for _, topic := range cfg.topics {
+		task := cfg.matchStr(topic)
+		if task == nil || topic == `help` {
+			continue
+		}
+		fmt.Fprintf(bw, "\n## %s %s\n", argv0, topic)
+		if task.use != `` {
+			fmt.Fprintf(bw, "\n%s\n", strings.TrimRight(task.use, "\r\n"))
+		}
+		if helper, ok := task.parser.(Helper); ok {
+			fmt.Fprintf(bw, "\n```\n%s\n```\n", strings.TrimRight(helper.Help(argv0+` `+topic), "\n"))
+		}
+		if len(task.settings) > 0 {
+			bw.WriteString("\n### Settings\n\n")
+			markdownSettings(bw, task.settings)
+		}
+	}
+	return bw.Flush()
+}
+
+func markdownSettings(w io.Writer, settings Settings) {
+	for _, it := range settings {
+		if value := it.value(); value != `` {
+			fmt.Fprintf(w, "- `%s`: %s (default: `%s`)\n", it.label(), it.Use, value)
+		} else {
+			fmt.Fprintf(w, "- `%s`: %s\n", it.label(), it.Use)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/parser"
+)
+
+func TestGenerateMarkdown(t *testing.T) {
+	port := 8080
+	var ran []string
+	var buf strings.Builder
+	err := GenerateMarkdown(&buf, Name(`test`), listTasks(&ran), Tasks{
+		{
+			Name:     `serve`,
+			Fn:       func(ctx context.Context) error { return nil },
+			Use:      "serves it\nuntil interrupted",
+			Parser:   parser.New(parser.Bool(new(bool), `open`, `o`, `opens a browser`)),
+			Settings: Settings{{Var: &port, Name: `PORT`, Use: `port to listen on`}},
+		},
+		{
+			Name:   `exec`,
+			Fn:     func(ctx context.Context) error { return nil },
+			Use:    `runs a | command`,
+			Parser: parser.Custom(), // does not implement Helper.
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := buf.String()
+	for _, want := range []string{
+		"# test\n",
+		"| Command | Description |\n| --- | --- |\n",
+		"| `test build` | builds it |\n",
+		"| `test list go-sources` | lists go sources |\n",
+		"| `test serve` | serves it |\n",
+		"| `test exec` | runs a \\| command |\n",
+		"\n## test serve\n\nserves it\nuntil interrupted\n",
+		"--open",
+		"\n### Settings\n\n- `PORT`: port to listen on (default: `8080`)\n",
+		"\n## test exec\n\nruns a | command\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document does not contain %q:\n%s", want, doc)
+		}
+	}
+	if strings.Contains(doc, "## test exec\n\nruns a | command\n\n```") {
+		t.Errorf("document explains flags for a parser without help:\n%s", doc)
+	}
+}
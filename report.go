@@ -0,0 +1,73 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/swdunlop/zugzug-go/zug"
+)
+
+// Report writes a JSON report to path describing each command run, how long it took, whether it was skipped, and its
+// error, if any.  The report is rewritten after each command, so it is complete whether the run succeeds or fails.
+//
+// Report is built on Before and After, so it only describes the commands selected by the command line; dependencies a
+// command runs with zug.Run or zug.Start are part of the time and error of that command, not separate entries.
+func Report(path string) Option {
+	r := &report{path: path}
+	return Apply(
+		Before(func(ctx context.Context) (context.Context, error) {
+			return context.WithValue(ctx, ctxReportStart{}, time.Now()), nil
+		}),
+		After(r.record),
+	)
+}
+
+type ctxReportStart struct{}
+
+type report struct {
+	path    string
+	control sync.Mutex
+	entries []reportEntry
+}
+
+type reportEntry struct {
+	Task     string  `json:"task"`
+	Duration float64 `json:"duration"` // in seconds
+	Skipped  bool    `json:"skipped,omitempty"`
+	Error    string  `json:"error,omitempty"`
+}
+
+func (r *report) record(ctx context.Context, err error) error {
+	entry := reportEntry{Task: CommandName(ctx)}
+	if start, ok := ctx.Value(ctxReportStart{}).(time.Time); ok {
+		entry.Duration = time.Since(start).Seconds()
+	}
+	switch {
+	case errors.Is(err, zug.ErrSkip):
+		entry.Skipped = true
+	case err != nil:
+		entry.Error = err.Error()
+	}
+
+	r.control.Lock()
+	defer r.control.Unlock()
+	r.entries = append(r.entries, entry)
+	js, jsErr := json.MarshalIndent(r.entries, ``, `  `)
+	if jsErr == nil {
+		jsErr = os.WriteFile(r.path, append(js, '\n'), 0o644)
+	}
+	if err == nil {
+		err = jsErr
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `report.json`)
+	z := mustNew(t, Report(path), KeepGoing(), Tasks{
+		{Name: `pass`, Fn: func(ctx context.Context) error { return nil }},
+		{Name: `fail`, Fn: func(ctx context.Context) error { return errors.New(`broken`) }},
+		{Name: `skip`, Fn: func(ctx context.Context) error { return fmt.Errorf(`%w: up to date`, zug.ErrSkip) }},
+	})
+	ctx, _, _ := consoletest.New()
+	if err := z.Run(ctx, `pass`, `fail`, `skip`); err == nil {
+		t.Fatal(`expected the failure to be returned`)
+	}
+
+	js, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []reportEntry
+	if err := json.Unmarshal(js, &entries); err != nil {
+		t.Fatal(err)
+	}
+	var got []reportEntry
+	for _, entry := range entries {
+		if entry.Duration < 0 {
+			t.Errorf(`%v has a negative duration`, entry.Task)
+		}
+		entry.Duration = 0
+		got = append(got, entry)
+	}
+	want := []reportEntry{
+		{Task: `pass`},
+		{Task: `fail`, Error: `fail: broken`},
+		{Task: `skip`, Skipped: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got report %+v\nwant %+v", got, want)
+	}
+}
@@ -9,36 +9,134 @@ package zugzug
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/swdunlop/zugzug-go/zug"
 	"github.com/swdunlop/zugzug-go/zug/console"
 )
 
 // Settings provide a way to configure data from an environment.
-type Settings []struct {
-	Var  any
-	Name string
-	Use  string
+type Settings []Setting
+
+// A Setting binds a variable to a named value from the environment.  Fields may be added in the future, so literals
+// should name their fields.
+type Setting struct {
+	Var    any
+	Name   string
+	Names  []string // fallbacks that are checked, in order, if Name is empty or not present
+	Use    string
+	Secret bool // if true, the value will be masked when explained
+}
+
+// lookup returns the value for the first of the setting's names that is present.
+func (it Setting) lookup(lookup func(string) (string, bool)) (string, bool) {
+	if it.Name != `` {
+		if value, ok := lookup(it.Name); ok {
+			return value, true
+		}
+	}
+	for _, name := range it.Names {
+		if value, ok := lookup(name); ok {
+			return value, true
+		}
+	}
+	return ``, false
+}
+
+// label returns the setting's names, in order, for explanations.
+func (it Setting) label() string {
+	names := it.Names
+	if it.Name != `` {
+		names = append([]string{it.Name}, names...)
+	}
+	return strings.Join(names, ` or `)
 }
 
+// value returns the current value of the setting for explanations, masked if it is a secret.
+func (it Setting) value() string { return maskSecret(get(it.Var), it.Secret) }
+
 // Apply will resolve settings by name using the provided lookup function, stopping at the first error.
 func (seq Settings) Apply(lookup func(string) (string, bool)) error {
 	for _, it := range seq {
-		if it.Name == `` {
-			continue
-		}
-		if value, ok := lookup(it.Name); ok {
+		if value, ok := it.lookup(lookup); ok {
 			if err := set(it.Var, value); err != nil {
-				return err
+				return fmt.Errorf(`%w for %s`, err, it.label())
 			}
 		}
 	}
 	return nil
 }
 
+// EnvCommand adds an "env" command that explains every setting used by any command, along with the value that would
+// be used based on the current environment.  Secret values are masked.
+func EnvCommand() Option {
+	return fnOption(func(cfg *config) {
+		cfg.bindTask(zug.Alias(`env`, zug.New(cfg.provideEnv)), nil, nil, `explains settings and their current values`)
+	})
+}
+
+func (cfg *config) provideEnv(ctx context.Context) error {
+	lookup, err := cfg.settingsLookup(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(console.From(ctx).Stdout(), 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+	for _, it := range cfg.allSettings() {
+		value, ok := it.lookup(lookup)
+		if !ok {
+			value = get(it.Var)
+		}
+		fmt.Fprintf(tw, "%s \t%s \t%q\n", it.label(), it.Use, maskSecret(value, it.Secret))
+	}
+	return nil
+}
+
+// allSettings returns the global settings followed by the settings of each task, skipping repeated labels.
+func (cfg *config) allSettings() Settings {
+	explained := make(map[string]struct{}, len(cfg.tasks))
+	all := append(Settings(nil), cfg.globalSettings...)
+	for _, task := range cfg.tasks {
+		all = append(all, task.settings...)
+	}
+	settings := all[:0]
+	for _, it := range all {
+		label := it.label()
+		if _, ok := explained[label]; ok || label == `` {
+			continue
+		}
+		explained[label] = struct{}{}
+		settings = append(settings, it)
+	}
+	return settings
+}
+
+// maskSecret replaces a non-empty value with asterisks if it is a secret.
+func maskSecret(value string, secret bool) string {
+	if secret && value != `` {
+		return `********`
+	}
+	return value
+}
+
 // get will get the value of a variable as a string.
 func get(target any) string {
+	switch target := target.(type) {
+	case interface {
+		Set(string) error
+		String() string
+	}:
+		return target.String()
+	case *[]string:
+		if target != nil {
+			return strings.Join(*target, `,`)
+		}
+	}
 	// target is likely a pointer to a value, so we need to dereference it
 	targetValue := reflect.ValueOf(target)
 	switch targetValue.Kind() {
@@ -53,30 +151,172 @@ func get(target any) string {
 
 // set will set the value of a variable.
 func set(target any, value string) error {
+	if setter, ok := target.(interface{ Set(string) error }); ok {
+		return setter.Set(value) // like pflag.Value, so flag types can be reused for settings.
+	}
 	switch target := target.(type) {
 	case *string:
 		*target = value
+	case *[]string:
+		*target = nil
+		if value != `` {
+			*target = strings.Split(value, `,`)
+		}
+	case *bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf(`%q is not a boolean`, value)
+		}
+		*target = v
+	case *int:
+		v, err := strconv.ParseInt(value, 0, strconv.IntSize)
+		if err != nil {
+			return fmt.Errorf(`%q is not an integer`, value)
+		}
+		*target = int(v)
+	case *int64:
+		v, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			return fmt.Errorf(`%q is not an integer`, value)
+		}
+		*target = v
+	case *uint:
+		v, err := strconv.ParseUint(value, 0, strconv.IntSize)
+		if err != nil {
+			return fmt.Errorf(`%q is not an unsigned integer`, value)
+		}
+		*target = uint(v)
+	case *float64:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf(`%q is not a number`, value)
+		}
+		*target = v
+	case *time.Duration:
+		v, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf(`%q is not a duration`, value)
+		}
+		*target = v
 	default:
 		_, err := fmt.Sscan(value, target)
 		if err != nil {
-			return fmt.Errorf(`%w in %q`, err, target)
+			return fmt.Errorf(`%w parsing %q`, err, value)
 		}
 	}
 	return nil
 }
 
-// envLookup will compose a lookup function from the provided context.
-func envLookup(ctx context.Context) func(string) (string, bool) {
+// settingsLookup composes a lookup function for settings from the console environment, any config files, and the
+// SettingPrefix, with overrides from WithSettings taking precedence over all of them.
+func (cfg *config) settingsLookup(ctx context.Context) (func(string) (string, bool), error) {
+	lookup := envLookup(ctx, cfg.foldSettings)
+	if len(cfg.configFiles) > 0 {
+		table := make(map[string]string)
+		for _, path := range cfg.configFiles {
+			if dir := console.From(ctx).Dir(); dir != `` && !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			err := readConfigFile(table, path)
+			if err != nil {
+				return nil, err
+			}
+		}
+		lookupEnv := lookup
+		lookup = func(name string) (string, bool) {
+			if str, ok := lookupEnv(name); ok {
+				return str, true
+			}
+			str, ok := table[name]
+			return str, ok
+		}
+	}
+	if prefix := cfg.settingPrefix; prefix != `` {
+		lookupBare := lookup
+		lookup = func(name string) (string, bool) {
+			if str, ok := lookupBare(prefix + name); ok {
+				return str, true
+			}
+			return lookupBare(name)
+		}
+	}
+	return withOverrides(ctx, cfg.foldSettings, lookup), nil
+}
+
+// SettingPrefix causes settings to be looked up with the provided prefix first, so a setting named "PORT" uses
+// "MYAPP_PORT" if it is set, then "PORT".
+func SettingPrefix(prefix string) Option {
+	return fnOption(func(cfg *config) { cfg.settingPrefix = prefix })
+}
+
+// FoldSettings causes settings to match environment variables without regard to case, so a setting named "PORT"
+// uses "port" or "Port" from the environment.
+func FoldSettings() Option {
+	return fnOption(func(cfg *config) { cfg.foldSettings = true })
+}
+
+// WithSettings returns a context where settings with the provided names take the provided values instead of values
+// from the environment, which is useful for testing commands without altering the process environment.
+func WithSettings(ctx context.Context, settings map[string]string) context.Context {
+	if prev, ok := ctx.Value(ctxSettings{}).(map[string]string); ok {
+		merged := make(map[string]string, len(prev)+len(settings))
+		for name, value := range prev {
+			merged[name] = value
+		}
+		for name, value := range settings {
+			merged[name] = value
+		}
+		settings = merged
+	}
+	return context.WithValue(ctx, ctxSettings{}, settings)
+}
+
+type ctxSettings struct{}
+
+// withOverrides wraps lookup so names given to WithSettings take the provided values.  If fold is true, names are
+// matched without regard to case.
+func withOverrides(ctx context.Context, fold bool, lookup func(string) (string, bool)) func(string) (string, bool) {
+	overrides, _ := ctx.Value(ctxSettings{}).(map[string]string)
+	if len(overrides) == 0 {
+		return lookup
+	}
+	key := func(name string) string { return name }
+	if fold {
+		key = strings.ToUpper
+	}
+	table := make(map[string]string, len(overrides))
+	for name, value := range overrides {
+		table[key(name)] = value
+	}
+	return func(name string) (string, bool) {
+		if str, ok := table[key(name)]; ok {
+			return str, true
+		}
+		return lookup(name)
+	}
+}
+
+// envLookup will compose a lookup function from the provided context.  Values are split from names at the first "=",
+// so "FOO=a=b" has the value "a=b", while a bare name like "FOO" is present with an empty value.  Entries that start
+// with "=", which Windows uses for per-drive directories, are skipped.  If fold is true, names are matched without
+// regard to case.
+func envLookup(ctx context.Context, fold bool) func(string) (string, bool) {
+	key := func(name string) string { return name }
+	if fold {
+		key = strings.ToUpper
+	}
 	env := console.From(ctx).Env()
 	table := make(map[string]string, len(env))
 	for _, it := range env {
-		if i := strings.IndexByte(it, '='); i > 0 {
-			table[it[:i]] = it[i+1:]
+		switch i := strings.IndexByte(it, '='); {
+		case i > 0:
+			table[key(it[:i])] = it[i+1:]
+		case i < 0 && it != ``:
+			table[key(it)] = ``
 		}
 	}
-
 	return func(name string) (string, bool) {
-		str, ok := table[name]
+		str, ok := table[key(name)]
 		return str, ok
 	}
 }
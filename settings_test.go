@@ -0,0 +1,289 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestEnvCommand(t *testing.T) {
+	var port int
+	var host, token string
+	fn := func(ctx context.Context) error { return nil }
+	z := mustNew(t, EnvCommand(), Tasks{
+		{Name: `serve`, Fn: fn, Settings: Settings{
+			{Var: &port, Name: `PORT`, Use: `port to listen on`},
+			{Var: &token, Name: `TOKEN`, Use: `api token`, Secret: true},
+		}},
+		{Name: `check`, Fn: fn, Settings: Settings{
+			{Var: &port, Name: `PORT`, Use: `port to listen on`},
+			{Var: &host, Name: `HOST`, Use: `host to check`},
+		}},
+	})
+	host = `localhost`
+	ctx, stdout, _ := consoletest.New(console.FullEnv([]string{`PORT=8080`, `TOKEN=hunter2`}))
+	if err := z.Run(ctx, `env`); err != nil {
+		t.Fatal(err)
+	}
+	out := stdout.String()
+	for _, want := range []string{`PORT`, `port to listen on`, `"8080"`, `TOKEN`, `"********"`, `HOST`, `"localhost"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("env does not list %q:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, `PORT`); n != 1 {
+		t.Errorf("env lists PORT %v times, want once:\n%s", n, out)
+	}
+	if strings.Contains(out, `hunter2`) {
+		t.Errorf("env shows a secret:\n%s", out)
+	}
+}
+
+func TestSettingNames(t *testing.T) {
+	for _, tc := range []struct {
+		env  map[string]string
+		want int
+	}{
+		{map[string]string{`SERVICE_PORT`: `1`, `PORT`: `2`}, 1},
+		{map[string]string{`PORT`: `2`}, 2},
+		{map[string]string{`OTHER_PORT`: `3`}, 80},
+	} {
+		port := 80
+		settings := Settings{{Var: &port, Names: []string{`SERVICE_PORT`, `PORT`}}}
+		err := settings.Apply(func(name string) (string, bool) {
+			value, ok := tc.env[name]
+			return value, ok
+		})
+		if err != nil || port != tc.want {
+			t.Errorf(`%v: got %v, %v, want %v`, tc.env, port, err, tc.want)
+		}
+	}
+
+	port := 0
+	settings := Settings{{Var: &port, Name: `SERVICE_PORT`, Names: []string{`PORT`}}}
+	err := settings.Apply(func(name string) (string, bool) { return `x`, name == `PORT` })
+	if err == nil || !strings.Contains(err.Error(), `for SERVICE_PORT or PORT`) {
+		t.Errorf(`got %v, want an error naming both names`, err)
+	}
+}
+
+func TestGlobalSettings(t *testing.T) {
+	var region string
+	var replicas int
+	newTool := func() Interface {
+		region, replicas = ``, 0
+		return mustNew(t, GlobalSettings(Settings{{Var: &region, Name: `REGION`, Use: `region to use`}}), Tasks{
+			{Name: `build`, Fn: testTask},
+			{Name: `deploy`, Fn: testTask, Settings: Settings{
+				{Var: &replicas, Name: `REPLICAS`, Use: `replicas to run`},
+			}},
+		})
+	}
+	env := console.FullEnv([]string{`REGION=east`, `REPLICAS=3`})
+
+	ctx, _, _ := consoletest.New(env)
+	if err := newTool().Run(ctx, `build`); err != nil {
+		t.Fatal(err)
+	}
+	if region != `east` || replicas != 0 {
+		t.Errorf(`build applied region %q and replicas %v, want only the region`, region, replicas)
+	}
+	ctx, _, _ = consoletest.New(env)
+	if err := newTool().Run(ctx, `deploy`); err != nil {
+		t.Fatal(err)
+	}
+	if region != `east` || replicas != 3 {
+		t.Errorf(`deploy applied region %q and replicas %v, want both`, region, replicas)
+	}
+
+	ctx, _, stderr := consoletest.New()
+	if err := newTool().Run(ctx, `help`); err != nil {
+		t.Fatal(err)
+	}
+	_, rest, ok := strings.Cut(stderr.String(), `GLOBAL SETTINGS:`)
+	if !ok {
+		t.Fatalf("help has no global settings:\n%s", stderr.String())
+	}
+	global, local, _ := strings.Cut(rest, "\nSETTINGS:")
+	if !strings.Contains(global, `REGION`) || strings.Contains(global, `REPLICAS`) {
+		t.Errorf("global settings should only list REGION:\n%s", stderr.String())
+	}
+	if !strings.Contains(local, `REPLICAS`) || strings.Contains(local, `REGION`) {
+		t.Errorf("settings should only list REPLICAS:\n%s", stderr.String())
+	}
+
+	ctx, _, stderr = consoletest.New()
+	if err := newTool().Run(ctx, `help`, `build`); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr.String(), `GLOBAL SETTINGS:`) || strings.Contains(stderr.String(), `REPLICAS`) {
+		t.Errorf("help for build should only list global settings:\n%s", stderr.String())
+	}
+}
+
+func TestSettingTypes(t *testing.T) {
+	var (
+		i   int
+		i64 int64
+		u   uint
+		f   float64
+		ss  []string
+		b   bool
+		d   time.Duration
+	)
+	for _, tc := range []struct {
+		target     any
+		value, got string // got is how get formats the value after it is set
+		wantErr    string
+	}{
+		{&i, `-42`, `-42`, ``},
+		{&i, `0x10`, `16`, ``},
+		{&i64, `9000000000`, `9000000000`, ``},
+		{&u, `7`, `7`, ``},
+		{&f, `2.5`, `2.5`, ``},
+		{&ss, `a,b,c`, `a,b,c`, ``},
+		{&ss, ``, ``, ``},
+		{&b, `true`, `true`, ``},
+		{&d, `90s`, `1m30s`, ``},
+		{&i, `many`, ``, `"many" is not an integer for SIZE`},
+		{&i64, `1.5`, ``, `"1.5" is not an integer for SIZE`},
+		{&u, `-1`, ``, `"-1" is not an unsigned integer for SIZE`},
+		{&f, `lots`, ``, `"lots" is not a number for SIZE`},
+		{&b, `maybe`, ``, `"maybe" is not a boolean for SIZE`},
+		{&d, `soon`, ``, `"soon" is not a duration for SIZE`},
+	} {
+		settings := Settings{{Var: tc.target, Name: `SIZE`}}
+		err := settings.Apply(func(string) (string, bool) { return tc.value, true })
+		switch {
+		case tc.wantErr != ``:
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf(`%T %q: got %v, want %q`, tc.target, tc.value, err, tc.wantErr)
+			}
+		case err != nil:
+			t.Errorf(`%T %q: %v`, tc.target, tc.value, err)
+		case get(tc.target) != tc.got:
+			t.Errorf(`%T %q: got %q, want %q`, tc.target, tc.value, get(tc.target), tc.got)
+		}
+	}
+}
+
+// hostPort is a setting value that must look like "host:port", with the same shape as pflag.Value.
+type hostPort struct{ host, port string }
+
+func (hp *hostPort) Set(value string) (err error) {
+	hp.host, hp.port, err = net.SplitHostPort(value)
+	return err
+}
+
+func (hp *hostPort) String() string { return net.JoinHostPort(hp.host, hp.port) }
+
+func (hp *hostPort) Type() string { return `host:port` }
+
+func TestSettingSetter(t *testing.T) {
+	addr := &hostPort{`localhost`, `80`}
+	settings := Settings{{Var: addr, Name: `ADDR`}}
+	if got := get(addr); got != `localhost:80` {
+		t.Errorf(`got %q for the default`, got)
+	}
+	if err := settings.Apply(func(string) (string, bool) { return `example.com:8080`, true }); err != nil {
+		t.Fatal(err)
+	}
+	if addr.host != `example.com` || addr.port != `8080` {
+		t.Errorf(`got %+v`, *addr)
+	}
+	err := settings.Apply(func(string) (string, bool) { return `no port`, true })
+	if err == nil || !strings.Contains(err.Error(), `missing port`) || !strings.HasSuffix(err.Error(), `for ADDR`) {
+		t.Errorf(`got %v, want the error from Set for ADDR`, err)
+	}
+}
+
+func TestWithSettings(t *testing.T) {
+	var port int
+	var host string
+	z := mustNew(t, Tasks{{Name: `serve`, Fn: testTask, Settings: Settings{
+		{Var: &port, Name: `PORT`, Use: `port to listen on`},
+		{Var: &host, Name: `HOST`, Use: `host to listen on`},
+	}}})
+	ctx, _, _ := consoletest.New(console.FullEnv([]string{`PORT=80`, `HOST=example.com`}))
+	ctx = WithSettings(ctx, map[string]string{`PORT`: `8080`})
+	ctx = WithSettings(ctx, map[string]string{`UNUSED`: `x`}) // merges with the earlier override.
+	if err := z.Run(ctx, `serve`); err != nil {
+		t.Fatal(err)
+	}
+	if port != 8080 || host != `example.com` {
+		t.Errorf(`got port %v and host %q, want the override for the port and the environment for the host`, port, host)
+	}
+}
+
+func TestWithSettingsPrefix(t *testing.T) {
+	var port int
+	z := mustNew(t, SettingPrefix(`MYAPP_`), Tasks{{Name: `serve`, Fn: testTask, Settings: Settings{
+		{Var: &port, Name: `PORT`, Use: `port to listen on`},
+	}}})
+	ctx, _, _ := consoletest.New(console.FullEnv([]string{`MYAPP_PORT=80`, `PORT=81`}))
+	ctx = WithSettings(ctx, map[string]string{`PORT`: `8080`})
+	if err := z.Run(ctx, `serve`); err != nil {
+		t.Fatal(err)
+	}
+	if port != 8080 {
+		t.Errorf(`got port %v, want the override instead of MYAPP_PORT`, port)
+	}
+}
+
+func TestEnvLookup(t *testing.T) {
+	ctx, _, _ := consoletest.New(console.FullEnv([]string{`FOO=a=b`, `BARE`, `=C:=C:\dir`, `EMPTY=`}))
+	lookup := envLookup(ctx, false)
+	for _, tc := range []struct {
+		name, value string
+		ok          bool
+	}{
+		{`FOO`, `a=b`, true},
+		{`BARE`, ``, true},
+		{`EMPTY`, ``, true},
+		{``, ``, false},
+		{`C:`, ``, false},
+		{`=C:`, ``, false},
+		{`MISSING`, ``, false},
+	} {
+		if value, ok := lookup(tc.name); value != tc.value || ok != tc.ok {
+			t.Errorf(`%q: got %q, %v, want %q, %v`, tc.name, value, ok, tc.value, tc.ok)
+		}
+	}
+}
+
+func TestSettingPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		options []Option
+		env     []string
+		want    int
+	}{
+		{[]Option{SettingPrefix(`MYAPP_`)}, []string{`MYAPP_PORT=1`, `PORT=2`}, 1},
+		{[]Option{SettingPrefix(`MYAPP_`)}, []string{`PORT=2`}, 2},
+		{[]Option{SettingPrefix(`MYAPP_`)}, []string{`myapp_port=3`}, 0},
+		{[]Option{SettingPrefix(`MYAPP_`), FoldSettings()}, []string{`myapp_port=3`, `PORT=2`}, 3},
+		{[]Option{FoldSettings()}, []string{`Port=4`}, 4},
+	} {
+		port := 0
+		z := mustNew(t, append(tc.options, Tasks{{Name: `serve`, Fn: testTask, Settings: Settings{
+			{Var: &port, Name: `PORT`},
+		}}})...)
+		ctx, _, _ := consoletest.New(console.FullEnv(tc.env))
+		if err := z.Run(ctx, `serve`); err != nil {
+			t.Fatal(err)
+		}
+		if port != tc.want {
+			t.Errorf(`%q: got port %v, want %v`, tc.env, port, tc.want)
+		}
+	}
+}
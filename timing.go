@@ -0,0 +1,38 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+)
+
+// Timing prints how long each command took to stderr after the commands finish, whether or not they succeed.
+func Timing() Option {
+	return fnOption(func(cfg *config) { cfg.timing = true })
+}
+
+type jobTiming struct {
+	name     string
+	duration time.Duration
+}
+
+func printTimings(ctx context.Context, timings []jobTiming) {
+	if len(timings) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(console.From(ctx).Stderr(), 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintln(tw, `TIMING:`)
+	for _, timing := range timings {
+		fmt.Fprintf(tw, "  %s \t%v\n", timing.name, timing.duration.Round(time.Millisecond))
+	}
+}
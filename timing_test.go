@@ -0,0 +1,43 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestTiming(t *testing.T) {
+	var ran []string
+	z := mustNew(t, Timing(), listTasks(&ran))
+	ctx, _, stderr := consoletest.New()
+	if err := z.Run(ctx, `build`, `list`, `go`); err != nil {
+		t.Fatal(err)
+	}
+	out := stderr.String()
+	if !strings.Contains(out, "TIMING:\n") {
+		t.Fatalf("no timing summary:\n%s", out)
+	}
+	for _, name := range []string{`build`, `list go`} {
+		rx := regexp.MustCompile(`(?m)^  ` + name + ` +[0-9.]+[a-zµ]*s$`)
+		if !rx.MatchString(out) {
+			t.Errorf("%q has no duration:\n%s", name, out)
+		}
+	}
+
+	z = mustNew(t, listTasks(&ran))
+	ctx, _, stderr = consoletest.New()
+	if err := z.Run(ctx, `build`); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stderr.String(), `TIMING`) {
+		t.Errorf("printed timing without the option:\n%s", stderr.String())
+	}
+}
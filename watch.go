@@ -0,0 +1,132 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/swdunlop/zugzug-go/zug"
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/worker"
+)
+
+// Watch causes Run to keep running after the selected commands finish, running them again with empty state whenever
+// a file under one of the paths changes.  Relative paths are resolved against the console Dir.  Errors are printed
+// instead of stopping the watch; interrupting the program ends it, and Run returns the error from the last run.
+//
+// Only commands from Tasks are watched; if the command line selects a built-in command, like help, it runs once.
+// Watch uses fsnotify, so paths are watched without polling, including directories created after the watch starts.
+func Watch(paths ...string) Option {
+	return fnOption(func(cfg *config) { cfg.watch = append(cfg.watch, paths...) })
+}
+
+// watchDelay is how long Watch waits for further changes before acting, so a burst of writes results in a single run.
+var watchDelay = 100 * time.Millisecond
+
+// watchable returns true if every job is a user task, so watching it makes sense.
+func watchable(jobs []job) bool {
+	for _, job := range jobs {
+		if job.fn == nil {
+			return false
+		}
+	}
+	return len(jobs) > 0
+}
+
+// watchJobs runs jobs, then runs them again with empty state each time a watched path changes until ctx is done,
+// returning the error from the last run.
+func (cfg *config) watchJobs(ctx context.Context, jobs []job) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf(`%w while starting to watch files`, err)
+	}
+	defer watcher.Close()
+	dir := console.From(ctx).Dir()
+	for _, path := range cfg.watch {
+		if dir != `` && !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if err := watchPath(watcher, path); err != nil {
+			return err
+		}
+	}
+
+	err = cfg.rerunJobs(ctx, jobs, false)
+	var delay <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return err
+		case err := <-watcher.Errors:
+			_ = console.PrintError(ctx, `!!`, err)
+		case event := <-watcher.Events:
+			if event.Has(fsnotify.Create) {
+				_ = watchPath(watcher, event.Name) // new directories must be watched, too.
+			}
+			delay = time.After(watchDelay)
+		case <-delay:
+			delay = nil
+			err = cfg.rerunJobs(ctx, jobs, true)
+			drainEvents(watcher) // ignore changes made by the jobs themselves.
+		}
+	}
+}
+
+// watchPath adds path to watcher, along with every directory under it.  Paths that do not exist are ignored, since
+// they may be created later.
+func watchPath(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return nil // the path may not exist yet, or may have been removed mid-walk.
+		case err != nil:
+			return err
+		case !entry.IsDir() && path != root:
+			return nil // files are watched through their directory.
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf(`%w while watching %q`, err, path)
+		}
+		return nil
+	})
+}
+
+// drainEvents discards any events waiting in watcher.
+func drainEvents(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case <-watcher.Events:
+		default:
+			return
+		}
+	}
+}
+
+// rerunJobs runs jobs, with empty state if fresh is true, printing and returning any error.
+func (cfg *config) rerunJobs(ctx context.Context, jobs []job, fresh bool) error {
+	if fresh {
+		jobs = append([]job(nil), jobs...)
+		for i, job := range jobs {
+			job.ctx = worker.With(job.ctx, worker.EmptyState())
+			if job.fn != nil {
+				job.task = zug.Alias(job.task.TaskName(), zug.New(job.fn))
+			}
+			jobs[i] = job
+		}
+	}
+	err := cfg.runJobs(ctx, ctx, jobs)
+	if err != nil && ctx.Err() == nil {
+		_ = console.PrintError(ctx, `!!`, err)
+	}
+	return err
+}
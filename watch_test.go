@@ -0,0 +1,78 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	runs := make(chan struct{}, 10)
+	errFailed := errors.New(`failed`)
+	z := mustNew(t, Watch(dir), Tasks{{Name: `build`, Fn: func(ctx context.Context) error {
+		runs <- struct{}{}
+		return errFailed
+	}}})
+	ctx, _, stderr := consoletest.New()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- z.Run(ctx, `build`) }()
+
+	waitFor := func(what string) {
+		t.Helper()
+		select {
+		case <-runs:
+		case err := <-done:
+			t.Fatalf(`Run returned %v while waiting for the %v run`, err, what)
+		case <-time.After(5 * time.Second):
+			t.Fatalf(`timed out waiting for the %v run`, what)
+		}
+	}
+	waitFor(`first`)
+	time.Sleep(50 * time.Millisecond) // give the watcher a moment to settle.
+	if err := os.WriteFile(filepath.Join(dir, `changed`), []byte(`changed`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(`second`)
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, errFailed) {
+			t.Errorf(`got %v, want the error from the last run`, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal(`timed out waiting for Run to return after cancel`)
+	}
+	if stderr.Len() == 0 {
+		t.Error(`watch did not print the error from each run`)
+	}
+}
+
+func TestWatchSkipsBuiltins(t *testing.T) {
+	z := mustNew(t, Watch(t.TempDir()), Tasks{{Name: `build`, Fn: func(ctx context.Context) error { return nil }}})
+	ctx, _, _ := consoletest.New()
+	done := make(chan error, 1)
+	go func() { done <- z.Run(ctx, `help`) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal(`help was watched instead of running once`)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/swdunlop/zugzug-go/zug"
+)
+
+// CacheDir is the directory, relative to the console Dir, where Cached records the hashes of its inputs.
+const CacheDir = `.zug-cache`
+
+// Cached wraps task so it is skipped with zug.ErrSkip if the files matching the inputs patterns have not changed since
+// it last succeeded and the files matching the outputs patterns exist.  Patterns are matched with Glob.  (This lives in
+// the console package because it resolves patterns against the console Dir.)
+func Cached(task zug.Task, inputs []string, outputs []string) zug.NamedTask {
+	return cachedTask{task, inputs, outputs}
+}
+
+type cachedTask struct {
+	task            zug.Task
+	inputs, outputs []string
+}
+
+func (t cachedTask) TaskName() string {
+	if named, ok := t.task.(zug.NamedTask); ok {
+		return named.TaskName()
+	}
+	return ``
+}
+
+func (t cachedTask) RunTask(ctx context.Context) error {
+	cfg := from(ctx)
+	hash, err := t.hash(ctx)
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(CacheDir, t.key())
+	if prev, err := os.ReadFile(cfg.path(name)); err == nil && bytes.Equal(prev, hash) && t.haveOutputs(ctx) {
+		return fmt.Errorf(`%w, inputs unchanged`, zug.ErrSkip)
+	}
+	err = t.task.RunTask(ctx)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(cfg.path(CacheDir), 0o755); err != nil {
+		return err
+	}
+	return WriteFile(ctx, name, hash, 0o644)
+}
+
+// key identifies the cache entry for the task, so different tasks and patterns do not share an entry.
+func (t cachedTask) key() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q\n%q\n%q\n", t.TaskName(), t.inputs, t.outputs)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hash returns a hash of the names and content of the files matching the inputs patterns.
+func (t cachedTask) hash(ctx context.Context) ([]byte, error) {
+	cfg := from(ctx)
+	h := sha256.New()
+	for _, pattern := range t.inputs {
+		names, err := Glob(ctx, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			info, err := os.Stat(cfg.path(name))
+			if err != nil {
+				return nil, err
+			}
+			if info.IsDir() {
+				continue
+			}
+			fmt.Fprintf(h, "%q\n", name)
+			if err = hashFile(ctx, h, cfg.path(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return []byte(hex.EncodeToString(h.Sum(nil))), nil
+}
+
+func hashFile(ctx context.Context, w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, ctxReader{ctx, f})
+	return err
+}
+
+// ctxReader stops reading with the context error once the context is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// haveOutputs returns true if each outputs pattern matches at least one file.
+func (t cachedTask) haveOutputs(ctx context.Context) bool {
+	for _, pattern := range t.outputs {
+		names, err := Glob(ctx, pattern)
+		if err != nil || len(names) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug"
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+// buildTask counts how often it runs, writing its output each time.
+type buildTask struct{ runs int }
+
+func (t *buildTask) TaskName() string { return `build` }
+
+func (t *buildTask) RunTask(ctx context.Context) error {
+	t.runs++
+	return console.WriteFile(ctx, `out.bin`, []byte(`built`), 0o644)
+}
+
+func TestCached(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, `main.go`)
+	if err := os.WriteFile(input, []byte(`package main`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	build := new(buildTask)
+	cached := console.Cached(build, []string{`*.go`}, []string{`out.bin`})
+	if cached.TaskName() != `build` {
+		t.Errorf(`got name %q, want "build"`, cached.TaskName())
+	}
+	ctx, _, _ := consoletest.New(console.Dir(dir))
+	for _, step := range []struct {
+		name    string
+		prepare func() error
+		runs    int
+		skip    bool
+	}{
+		{`miss`, func() error { return nil }, 1, false},
+		{`hit`, func() error { return nil }, 1, true},
+		{`changed input`, func() error { return os.WriteFile(input, []byte(`package main // changed`), 0o666) }, 2, false},
+		{`hit again`, func() error { return nil }, 2, true},
+		{`missing output`, func() error { return os.Remove(filepath.Join(dir, `out.bin`)) }, 3, false},
+	} {
+		if err := step.prepare(); err != nil {
+			t.Fatal(err)
+		}
+		err := cached.RunTask(ctx)
+		if err != nil && !errors.Is(err, zug.ErrSkip) {
+			t.Fatalf(`%v: %v`, step.name, err)
+		}
+		if build.runs != step.runs {
+			t.Errorf(`%v: ran %v times, want %v`, step.name, build.runs, step.runs)
+		}
+		if skipped := errors.Is(err, zug.ErrSkip); skipped != step.skip {
+			t.Errorf(`%v: got %v, want skipped to be %v`, step.name, err, step.skip)
+		}
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := cached.RunTask(canceled); !errors.Is(err, context.Canceled) {
+		t.Errorf(`got %v while hashing with a canceled context`, err)
+	}
+}
@@ -7,8 +7,10 @@
 package console
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -17,20 +19,40 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/swdunlop/zugzug-go/zug"
+	"github.com/swdunlop/zugzug-go/zug/console/dedupe"
+	"github.com/swdunlop/zugzug-go/zug/console/filter"
 	"github.com/swdunlop/zugzug-go/zug/console/indent"
+	"github.com/swdunlop/zugzug-go/zug/console/throttle"
+	"github.com/swdunlop/zugzug-go/zug/console/truncate"
 )
 
-// Print will print the provided arguments to the console's stdout using fmt.Println.
+// Print will print the provided arguments to the console's stdout using fmt.Println.  If stdout has been closed by
+// its reader, this returns ErrBrokenPipe.
 func Print(ctx context.Context, args ...interface{}) error {
 	_, err := fmt.Fprintln(from(ctx).stdout, args...)
-	return err
+	return checkBrokenPipe(err)
 }
 
-// Printf will print the provided arguments to the console's stdout using fmt.Printf.
+// Printf will print the provided arguments to the console's stdout using fmt.Printf.  If stdout has been closed by
+// its reader, this returns ErrBrokenPipe.
 func Printf(ctx context.Context, format string, args ...interface{}) error {
 	_, err := fmt.Fprintf(from(ctx).stdout, format, args...)
+	return checkBrokenPipe(err)
+}
+
+// ErrBrokenPipe is returned by Print and Printf when stdout has been closed by its reader, such as when piping output
+// to head.  zugzug.Main treats this as a clean exit.
+var ErrBrokenPipe = errors.New(`broken pipe`)
+
+// checkBrokenPipe replaces EPIPE errors with ErrBrokenPipe.
+func checkBrokenPipe(err error) error {
+	if errors.Is(err, syscall.EPIPE) {
+		return ErrBrokenPipe
+	}
 	return err
 }
 
@@ -46,6 +68,19 @@ func Errorf(ctx context.Context, format string, args ...interface{}) error {
 	return err
 }
 
+// PrintFailure will print "!!" followed by the provided arguments to the console's stderr using fmt.Println, the same
+// way Run reports a failed command.  The "!!" is red if stderr is a terminal, unless color is disabled.
+func PrintFailure(ctx context.Context, args ...interface{}) error {
+	cfg := from(ctx)
+	_, err := fmt.Fprintln(cfg.stderr, append([]interface{}{cfg.failurePrefix(cfg.stderr)}, args...)...)
+	return err
+}
+
+// PrintFailuref is like PrintFailure, but formats the message using fmt.Sprintf.
+func PrintFailuref(ctx context.Context, format string, args ...interface{}) error {
+	return PrintFailure(ctx, fmt.Sprintf(format, args...))
+}
+
 // Do will return a Zug task that will run the specified command.
 func Do(name string, args ...string) zug.NamedTask {
 	return zug.Alias(name, zug.New(func(ctx context.Context) error {
@@ -53,54 +88,280 @@ func Do(name string, args ...string) zug.NamedTask {
 	})).(zug.NamedTask)
 }
 
+// StartLabeled is like zug.Start, but prefixes each line of output from each task with its name, like "[build] ",
+// using Indent.
+func StartLabeled(ctx context.Context, tasks ...any) error {
+	return zug.StartWith(ctx, func(ctx context.Context, task string) context.Context {
+		return With(ctx, Indent(`[`+task+`] `))
+	}, tasks...)
+}
+
 // Eval will run the provided command with the provided arguments, returning the output and error if any.
 func Eval(ctx context.Context, name string, args ...string) (string, error) {
 	var buf bytes.Buffer
 	err := from(ctx).withCommand(ctx, name, args, func(cmd *exec.Cmd) error {
+		buf.Reset() // discard output from previous attempts.
 		cmd.Stdout = &buf
-		return cmd.Run()
+		return from(ctx).run(cmd)
 	})
 	return buf.String(), err
 }
 
+// Expect will run the provided command like Eval, returning an error if its output, with leading and trailing space
+// trimmed, is not want.
+func Expect(ctx context.Context, want string, name string, args ...string) error {
+	out, err := Eval(ctx, name, args...)
+	if err != nil {
+		return err
+	}
+	if got := strings.TrimSpace(out); got != want {
+		return fmt.Errorf(`expected %q to output %q, got %q`, name, want, got)
+	}
+	return nil
+}
+
+// ExpectMatch will run the provided command like Eval, returning an error if its output, with leading and trailing
+// space trimmed, does not match rx.
+func ExpectMatch(ctx context.Context, rx *regexp.Regexp, name string, args ...string) error {
+	out, err := Eval(ctx, name, args...)
+	if err != nil {
+		return err
+	}
+	if got := strings.TrimSpace(out); !rx.MatchString(got) {
+		return fmt.Errorf(`expected %q to output a match for %q, got %q`, name, rx, got)
+	}
+	return nil
+}
+
 // Run will run the provided command with the provided arguments, returning the error if any.
 func Run(ctx context.Context, name string, args ...string) (err error) {
 	return from(ctx).withCommand(ctx, name, args, func(cmd *exec.Cmd) error {
-		return cmd.Run()
+		return from(ctx).run(cmd)
 	})
 }
 
-func (cfg *config) withCommand(ctx context.Context, name string, args []string, do func(*exec.Cmd) error) (err error) {
-	cmd := cfg.command(ctx, name, args...)
-	var buf []byte
-	if cfg.verbosityValue != silentVerbosity {
-		buf = make([]byte, 0, 256)
-		buf = append(buf, ">> "...)
-		buf = AppendCommand(buf, cmd)
-		buf = append(buf, '\n')
+// RunCode is like Run, but returns the exit code of the command instead of treating a nonzero exit code as an error.
+func RunCode(ctx context.Context, name string, args ...string) (code int, err error) {
+	err = from(ctx).withCommand(ctx, name, args, func(cmd *exec.Cmd) error {
+		err := from(ctx).run(cmd)
+		if exitCode, ok := ExitCode(err); ok {
+			code = exitCode
+			return nil
+		}
+		return err
+	})
+	return code, err
+}
+
+// ExitCode returns the exit code from an *exec.ExitError in the chain of err, or false if there is none.
+func ExitCode(err error) (int, bool) {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), true
+	}
+	return 0, false
+}
+
+// RunLines is like Run, but calls fn with each line the command writes to stdout, without its line ending, as it is
+// written.  If fn returns an error, the command is killed and that error is returned.
+//
+// If Retry was specified, the output of each attempt is buffered instead, and fn is called with the lines from the
+// last attempt once it finishes, so fn never sees lines from an attempt that failed and was retried.
+func RunLines(ctx context.Context, fn func(line string) error, name string, args ...string) error {
+	if from(ctx).retryAttempts > 1 {
+		var buf bytes.Buffer
+		err := from(ctx).withCommand(ctx, name, args, func(cmd *exec.Cmd) error {
+			buf.Reset() // discard output from previous attempts.
+			cmd.Stdout = &buf
+			return from(ctx).run(cmd)
+		})
+		if scanErr := scanLines(&buf, fn); scanErr != nil {
+			return scanErr
+		}
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	return from(ctx).withCommand(ctx, name, args, func(cmd *exec.Cmd) error {
+		if hook := from(ctx).execHook; hook != nil {
+			var buf bytes.Buffer
+			cmd.Stdout = &buf
+			if err := hook(cmd); err != nil {
+				return err
+			}
+			return scanLines(&buf, fn)
+		}
+		cmd.Stdout = nil
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		if err = scanLines(stdout, fn); err != nil {
+			cancel() // kill the command, since we are no longer reading its output.
+			_ = cmd.Wait()
+			return err
+		}
+		return cmd.Wait()
+	})
+}
+
+// scanLines calls fn with each line from r, without its line ending, stopping at the first error.
+func scanLines(r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if err := fn(strings.TrimSuffix(scanner.Text(), "\r")); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// RunProgress is like Run, but counts the bytes the command writes to stdout, calling onProgress with the total at
+// most every 100ms while the command runs and once more after it finishes.
+func RunProgress(ctx context.Context, onProgress func(bytes int64), name string, args ...string) error {
+	pw := &progressWriter{onProgress: onProgress}
+	err := from(ctx).withCommand(ctx, name, args, func(cmd *exec.Cmd) error {
+		pw.total = 0 // count only the output of the last attempt.
+		pw.w = cmd.Stdout
+		if pw.w == nil {
+			pw.w = io.Discard
+		}
+		cmd.Stdout = pw
+		return from(ctx).run(cmd)
+	})
+	onProgress(pw.total)
+	return err
+}
+
+// progressWriter counts bytes written to w for RunProgress.
+type progressWriter struct {
+	w          io.Writer
+	onProgress func(int64)
+	total      int64
+	last       time.Time
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.total += int64(n)
+	if now := time.Now(); now.Sub(pw.last) >= 100*time.Millisecond {
+		pw.last = now
+		pw.onProgress(pw.total)
+	}
+	return n, err
+}
+
+// RunIfStale is like Run, but only runs the command if target is missing or was modified more than maxAge ago.  A
+// relative target is resolved against the console directory.
+func RunIfStale(ctx context.Context, target string, maxAge time.Duration, name string, args ...string) error {
+	cfg := from(ctx)
+	if cfg.dir != `` && !filepath.IsAbs(target) {
+		target = filepath.Join(cfg.dir, target)
+	}
+	info, err := os.Stat(target)
+	switch {
+	case err == nil && time.Since(info.ModTime()) <= maxAge:
+		return nil
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	return Run(ctx, name, args...)
+}
+
+// RunEnv is like Run, but adds the provided environment variables to the environment for this command, replacing any
+// with the same name.
+func RunEnv(ctx context.Context, env []string, name string, args ...string) error {
+	return from(ctx).withEnv(env).withCommand(ctx, name, args, func(cmd *exec.Cmd) error {
+		return from(ctx).run(cmd)
+	})
+}
+
+// EvalEnv is like Eval, but adds the provided environment variables to the environment for this command, replacing
+// any with the same name.
+func EvalEnv(ctx context.Context, env []string, name string, args ...string) (string, error) {
+	var buf bytes.Buffer
+	err := from(ctx).withEnv(env).withCommand(ctx, name, args, func(cmd *exec.Cmd) error {
+		buf.Reset() // discard output from previous attempts.
+		cmd.Stdout = &buf
+		return from(ctx).run(cmd)
+	})
+	return buf.String(), err
+}
+
+// withEnv returns a copy of the configuration with env appended to its environment.
+func (cfg *config) withEnv(env []string) *config {
+	next := *cfg
+	next.env = append(cfg.env[:len(cfg.env):len(cfg.env)], env...)
+	return &next
+}
+
+// withCommand runs a command using do, trying again as specified by Retry if it exits with a nonzero code.
+func (cfg *config) withCommand(ctx context.Context, name string, args []string, do func(*exec.Cmd) error) error {
+	cmd, err := cfg.tryCommand(ctx, name, args, do)
+	attempt := 1
+	for ; attempt < cfg.retryAttempts; attempt++ {
+		if _, ok := ExitCode(err); !ok {
+			break
+		}
+		if cfg.verbosityValue == normalVerbosity || cfg.verbosityValue == verboseVerbosity {
+			cfg.note(cfg.stderr, fmt.Sprintf(`retrying in %v, attempt %d of %d`, cfg.retryBackoff, attempt+1, cfg.retryAttempts))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(cfg.retryBackoff):
+		}
+		cmd, err = cfg.tryCommand(ctx, name, args, do)
+	}
+	if err != nil && attempt > 1 {
+		err = fmt.Errorf(`%w after %d attempts`, err, attempt)
+	}
+	if cfg.summary != nil && cmd != nil {
+		cfg.summary.record(cmd, err) // once, no matter how many attempts it took.
+	}
+	return err
+}
+
+// tryCommand runs a command using do, returning the command that ran, or nil if none did, and the error, if any.
+func (cfg *config) tryCommand(ctx context.Context, name string, args []string, do func(*exec.Cmd) error) (cmd *exec.Cmd, err error) {
+	args, err = cfg.expandArgs(args)
+	if err != nil {
+		if cfg.verbosityValue == normalVerbosity || cfg.verbosityValue == verboseVerbosity {
+			cfg.report(cfg.stderr, err)
+		}
+		return nil, err
+	}
+	cmd = cfg.command(ctx, name, args...)
+	if cfg.plan != nil {
+		cfg.plan.record(cmd)
+		return nil, nil
 	}
 	switch cfg.verbosityValue {
 	case normalVerbosity, quietVerbosity:
-		var stderr bytes.Buffer
 		if cfg.verbosityValue == normalVerbosity {
-			cfg.stderr.Write(buf)
-		} else {
-			stderr.Write(buf) // only show the command if it fails.
+			cfg.echo(cfg.stderr, cmd)
 		}
+		var stderr bytes.Buffer
 		stderr.Grow(256)
 		oldStderr := cmd.Stderr
 		cmd.Stderr = indent.Writer(&stderr, `   `)
 		defer func() {
 			if err != nil {
-				fmt.Fprintln(&stderr, `!!`, err)
+				if cfg.verbosityValue == quietVerbosity {
+					cfg.echo(oldStderr, cmd) // only show the command if it fails.
+				}
 				stderr.WriteTo(oldStderr)
+				cfg.report(oldStderr, err)
 			}
 		}()
 	case verboseVerbosity:
-		cfg.stderr.Write(buf)
+		cfg.echo(cfg.stderr, cmd)
 		defer func() {
 			if err != nil {
-				fmt.Fprintln(cfg.stderr, `!!`, err)
+				cfg.report(cfg.stderr, err)
 			}
 		}()
 		cmd.Stderr = indent.Writer(cmd.Stderr, `   `)
@@ -109,9 +370,172 @@ func (cfg *config) withCommand(ctx context.Context, name string, args []string,
 	}
 
 	err = do(cmd)
+	if code, ok := ExitCode(err); ok && cfg.allowsExit(code) {
+		if cfg.verbosityValue == normalVerbosity || cfg.verbosityValue == verboseVerbosity {
+			cfg.note(cfg.stderr, fmt.Sprintf(`exit status %d allowed`, code))
+		}
+		err = nil
+	}
+	_ = cfg.flush()
 	return
 }
 
+// WithPlan derives a new context where Run and Eval record their commands in the returned Plan instead of running
+// them, as if the commands succeeded with no output.  Commands made by Command are not affected.
+func WithPlan(ctx context.Context) (context.Context, *Plan) {
+	plan := new(Plan)
+	return With(ctx, func(cfg *config) { cfg.plan = plan }), plan
+}
+
+// A Plan records the commands that would be run by Run and Eval.  It is safe for concurrent use.
+type Plan struct {
+	control  sync.Mutex
+	commands []string
+}
+
+// Commands returns the commands that would have run, formatted by FormatCommand.
+func (p *Plan) Commands() []string {
+	p.control.Lock()
+	defer p.control.Unlock()
+	return append([]string(nil), p.commands...)
+}
+
+func (p *Plan) record(cmd *exec.Cmd) {
+	p.control.Lock()
+	defer p.control.Unlock()
+	p.commands = append(p.commands, FormatCommand(cmd))
+}
+
+// echo writes ">> " and the command to w, or passes the command to the EchoLogger with level "info".
+func (cfg *config) echo(w io.Writer, cmd *exec.Cmd) {
+	buf := make([]byte, 0, 256)
+	if cfg.echoLogger == nil {
+		buf = append(buf, ">> "...)
+	}
+	if cfg.showDir && !sameDir(cmd.Dir) {
+		buf = append(buf, "(in "...)
+		buf = appendPOSIXValue(buf, cmd.Dir)
+		buf = append(buf, ") "...)
+	}
+	buf = AppendCommand(buf, cmd)
+	if cfg.echoLogger != nil {
+		cfg.echoLogger(`info`, string(buf))
+		return
+	}
+	buf = append(buf, '\n')
+	w.Write(buf)
+}
+
+// sameDir returns true if dir is empty or refers to the current working directory of the process.
+func sameDir(dir string) bool {
+	if dir == `` {
+		return true
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	abs, err := filepath.Abs(dir)
+	return err == nil && filepath.Clean(abs) == filepath.Clean(wd)
+}
+
+// run runs cmd, or passes it to the ExecHook if there is one.
+func (cfg *config) run(cmd *exec.Cmd) error {
+	if cfg.execHook != nil {
+		return cfg.execHook(cmd)
+	}
+	return cmd.Run()
+}
+
+// note writes "-- " and the message to w, or passes the message to the EchoLogger with level "info".
+func (cfg *config) note(w io.Writer, msg string) {
+	if cfg.echoLogger != nil {
+		cfg.echoLogger(`info`, msg)
+		return
+	}
+	fmt.Fprintln(w, `--`, msg)
+}
+
+// allowsExit returns true if AllowExit specified the exit code.
+func (cfg *config) allowsExit(code int) bool {
+	for _, allowed := range cfg.allowedExits {
+		if code == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// report writes "!! " and the error to w, or passes the error to the EchoLogger with level "error".
+func (cfg *config) report(w io.Writer, err error) {
+	if cfg.echoLogger != nil {
+		cfg.echoLogger(`error`, err.Error())
+		return
+	}
+	fmt.Fprintln(w, cfg.failurePrefix(w), err)
+}
+
+// failurePrefix returns "!!", in red if w is a terminal and color has not been disabled.
+func (cfg *config) failurePrefix(w io.Writer) string {
+	if cfg.noColor || !isTerminal(w) {
+		return `!!`
+	}
+	if _, ok := lookupEnv(cfg.env, `NO_COLOR`); ok {
+		return `!!`
+	}
+	return "\x1b[31m!!\x1b[0m"
+}
+
+// WithCommandSummary derives a new context with a Summary that counts the commands run by Run and Eval.
+func WithCommandSummary(ctx context.Context) (context.Context, *Summary) {
+	summary := new(Summary)
+	return With(ctx, func(cfg *config) { cfg.summary = summary }), summary
+}
+
+// A Summary counts the commands run by Run and Eval and remembers which failed.  It is safe for concurrent use.
+type Summary struct {
+	control   sync.Mutex
+	succeeded int
+	failures  []Failure
+}
+
+// A Failure describes a command that failed.
+type Failure struct {
+	Command string // formatted by FormatCommand
+	Err     error
+}
+
+// Succeeded returns the number of commands that succeeded.
+func (s *Summary) Succeeded() int {
+	s.control.Lock()
+	defer s.control.Unlock()
+	return s.succeeded
+}
+
+// Failures returns the commands that failed, in the order they finished.
+func (s *Summary) Failures() []Failure {
+	s.control.Lock()
+	defer s.control.Unlock()
+	return append([]Failure(nil), s.failures...)
+}
+
+// String returns a line like "5 commands succeeded, 1 failed".
+func (s *Summary) String() string {
+	s.control.Lock()
+	defer s.control.Unlock()
+	return fmt.Sprintf(`%v commands succeeded, %v failed`, s.succeeded, len(s.failures))
+}
+
+func (s *Summary) record(cmd *exec.Cmd, err error) {
+	s.control.Lock()
+	defer s.control.Unlock()
+	if err == nil {
+		s.succeeded++
+	} else {
+		s.failures = append(s.failures, Failure{FormatCommand(cmd), err})
+	}
+}
+
 //	func Console() console.Option {
 //		return console.Hook(func(ctx context.Context, cmd *exec.Cmd) func(error) error {
 //			if !Quiet || Verbose {
@@ -146,13 +570,27 @@ func (cfg *config) withCommand(ctx context.Context, name string, args []string,
 //		}
 //
 // Command returns a new command with the provided name and arguments with Dir, Stdout, Stderr, Stdin and Environment
-// configured by the console.
+// configured by the console.  If ExpandArgs or ExpandArgsStrict is in effect, arguments are expanded, but undefined
+// variables always expand to an empty string.
 func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
-	return from(ctx).command(ctx, name, args...)
+	cfg := *from(ctx)
+	if cfg.expand == expandArgsStrict {
+		cfg.expand = expandArgs
+	}
+	args, _ = cfg.expandArgs(args) // only strict expansion returns an error.
+	return cfg.command(ctx, name, args...)
 }
 
 func (cfg *config) command(ctx context.Context, name string, args ...string) *exec.Cmd {
-	cmd := exec.CommandContext(ctx, name, args...)
+	for _, rewrite := range cfg.rewrites {
+		name, args = rewrite(name, args)
+	}
+	path := name
+	if found, err := lookPath(name, cfg.env); err == nil {
+		path = found // use the console PATH, not the process PATH.
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Args[0] = name
 	cmd.Dir = cfg.dir
 	cmd.Stdout = cfg.stdout
 	cmd.Stderr = cfg.stderr
@@ -192,6 +630,10 @@ type Interface interface {
 	// Dir returns the current working directory.
 	Dir() string
 
+	// AbsDir returns the current working directory as an absolute path, using the process working directory if Dir is
+	// empty.
+	AbsDir() (string, error)
+
 	// Env returns the current environment.
 	Env() []string
 
@@ -201,6 +643,18 @@ type Interface interface {
 	// Stderr returns the current stderr writer.
 	Stderr() io.Writer
 
+	// Stdin returns the current stdin reader, which is os.Stdin unless the Stdin option specified another.
+	Stdin() io.Reader
+
+	// IsVerbose is true if the console was configured with Verbose, so tasks may describe what they are doing.
+	IsVerbose() bool
+
+	// IsQuiet is true if the console was configured with Quiet, so tasks should only report errors.
+	IsQuiet() bool
+
+	// IsSilent is true if the console was configured with Silent, so tasks should produce no output at all.
+	IsSilent() bool
+
 	// verbosity returns the current verbosity for Run and Eval.  (This does not affect Command.)
 	//
 	// This defaults to printing the commands that are run, but not relaying stderr.
@@ -217,6 +671,41 @@ func TeeStderr(w io.Writer) Option {
 	return func(cfg *config) { cfg.stderr = io.MultiWriter(w, cfg.stderr) }
 }
 
+// MergeStderr sends everything written to stderr to stdout instead, including command logging and the stderr of
+// commands, which is still only relayed as verbosity allows.
+func MergeStderr() Option {
+	return func(cfg *config) { cfg.stderr = cfg.stdout }
+}
+
+// LogFile creates or truncates the file at path and returns an option that copies stdout and stderr to it using
+// TeeStdout and TeeStderr, along with a closer for the file.  The file is not buffered, so nothing is lost if the
+// program exits before it is closed.
+func LogFile(path string) (Option, io.Closer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Apply(TeeStdout(f), TeeStderr(f)), f, nil
+}
+
+// ResetStdout restores stdout to the default console's stdout, normally os.Stdout, removing any tees or other writers
+// wrapped around it.  This does not change verbosity, so Silent still suppresses command logging.
+func ResetStdout() Option {
+	return func(cfg *config) {
+		initDefaultConfigOnce.Do(initDefaultConfig)
+		cfg.stdout = defaultConfig.stdout
+	}
+}
+
+// ResetStderr restores stderr to the default console's stderr, normally os.Stderr, removing any tees or other writers
+// wrapped around it.  This does not change verbosity, so Silent still suppresses command logging.
+func ResetStderr() Option {
+	return func(cfg *config) {
+		initDefaultConfigOnce.Do(initDefaultConfig)
+		cfg.stderr = defaultConfig.stderr
+	}
+}
+
 // TeeStdin copies input read from stdin to the specified writer.
 func TeeStdin(w io.Writer) Option {
 	return func(cfg *config) { cfg.stdin = io.TeeReader(cfg.stdin, w) }
@@ -230,6 +719,116 @@ func Indent(str string) Option {
 	}
 }
 
+// Retry causes Run, Eval and their variants to run a command up to attempts times, waiting for backoff between
+// attempts, until it exits with a zero exit code.  Each retry is noted unless the console is quiet or silent.  A
+// Summary counts a retried command once, with the result of its last attempt.
+func Retry(attempts int, backoff time.Duration) Option {
+	return func(cfg *config) { cfg.retryAttempts, cfg.retryBackoff = attempts, backoff }
+}
+
+// ExecHook causes Run, Eval and their variants to call hook instead of running commands, so tests can check the
+// commands that tasks run without running them.  The hook may write to cmd.Stdout and cmd.Stderr to simulate output,
+// and the error it returns is treated like the error from running the command.  Commands are still echoed.
+func ExecHook(hook func(cmd *exec.Cmd) error) Option {
+	return func(cfg *config) { cfg.execHook = hook }
+}
+
+// AllowExit specifies exit codes that Run and Eval treat as success, like 1 from grep when nothing matches.  Unless
+// the console is quiet or silent, the exit code is still noted.
+func AllowExit(codes ...int) Option {
+	return func(cfg *config) {
+		cfg.allowedExits = append(cfg.allowedExits[:len(cfg.allowedExits):len(cfg.allowedExits)], codes...)
+	}
+}
+
+// EchoLogger specifies a function that will receive the commands run by Run and Eval, with level "info", and their
+// errors, with level "error", instead of writing them to stderr.  The console verbosity still determines which are
+// logged, and output from commands is still written to stderr.
+func EchoLogger(logf func(level, msg string)) Option {
+	return func(cfg *config) { cfg.echoLogger = logf }
+}
+
+// DedupeLines collapses consecutive identical lines written to stdout into one line with a suffix like " (x3)".  The
+// most recent line is held back until a different line is written, a command finishes, or Flush is called.  This does
+// not affect output captured by Eval.
+func DedupeLines() Option {
+	return func(cfg *config) { cfg.stdout = dedupe.Writer(cfg.stdout) }
+}
+
+// Filter only passes lines written to stdout that match rx.  An incomplete final line is held back until a command
+// finishes or Flush is called.
+func Filter(rx *regexp.Regexp) Option {
+	return func(cfg *config) { cfg.stdout = filter.Writer(cfg.stdout, rx.Match) }
+}
+
+// FilterOut only passes lines written to stdout that do not match rx.  An incomplete final line is held back until a
+// command finishes or Flush is called.
+func FilterOut(rx *regexp.Regexp) Option {
+	return func(cfg *config) {
+		cfg.stdout = filter.Writer(cfg.stdout, func(line []byte) bool { return !rx.Match(line) })
+	}
+}
+
+// Throttle passes through the first maxLines lines written to stdout and stderr, then suppresses the rest, writing a
+// summary of how many lines were suppressed periodically and when a command finishes or Flush is called.
+func Throttle(maxLines int) Option {
+	return func(cfg *config) {
+		cfg.stdout = throttle.Writer(cfg.stdout, maxLines)
+		cfg.stderr = throttle.Writer(cfg.stderr, maxLines)
+	}
+}
+
+// Flush writes any output being held back by options like DedupeLines.
+func Flush(ctx context.Context) error {
+	return from(ctx).flush()
+}
+
+func (cfg *config) flush() error {
+	for _, w := range []io.Writer{cfg.stdout, cfg.stderr} {
+		if f, ok := w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TruncateLines truncates lines written to stdout and stderr to n runes, followed by truncate.Marker, when they are
+// terminals.  This does not affect output captured by Eval.
+func TruncateLines(n int) Option {
+	return func(cfg *config) {
+		if isTerminal(cfg.stdout) {
+			cfg.stdout = truncate.Writer(cfg.stdout, n)
+		}
+		if isTerminal(cfg.stderr) {
+			cfg.stderr = truncate.Writer(cfg.stderr, n)
+		}
+	}
+}
+
+// isTerminal returns true if w is a file for a character device, like a TTY.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Color specifies whether the console may use color when writing to a terminal.  Color is enabled by default unless
+// NO_COLOR is set in the environment.
+func Color(enabled bool) Option {
+	return func(cfg *config) { cfg.noColor = !enabled }
+}
+
+// ShowDir specifies that echoed commands should include their working directory, as in ">> (in /path) command", when it
+// differs from the working directory of the process.
+func ShowDir() Option {
+	return func(cfg *config) { cfg.showDir = true }
+}
+
 // Silent specifies that the console must never produce output for any reason.
 func Silent() Option {
 	return func(cfg *config) { cfg.verbosityValue = silentVerbosity }
@@ -298,11 +897,32 @@ func Env(env ...string) Option {
 	return func(cfg *config) { cfg.env = append(cfg.env, env...) }
 }
 
+// TempDir creates a temporary directory named after the program and derives a new context using it as the working
+// directory.  The returned function removes the directory and may be called more than once, such as from a defer.
+func TempDir(ctx context.Context) (context.Context, func(), error) {
+	prefix := strings.TrimSuffix(filepath.Base(os.Args[0]), `.exe`)
+	dir, err := os.MkdirTemp(``, prefix+`-*`)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+	var once sync.Once
+	cleanup := func() { once.Do(func() { _ = os.RemoveAll(dir) }) }
+	return With(ctx, Dir(dir)), cleanup, nil
+}
+
 // Dir specifies the working directory for the console.
 func Dir(dir string) Option {
 	return func(cfg *config) { cfg.dir = dir }
 }
 
+// RewriteCommand adds a function that may replace the name and arguments of each command before it is run, such as
+// substituting podman for docker.  Rewrites apply in the order they were added, and the echoed command reflects them.
+func RewriteCommand(rewrite func(name string, args []string) (string, []string)) Option {
+	return func(cfg *config) {
+		cfg.rewrites = append(cfg.rewrites[:len(cfg.rewrites):len(cfg.rewrites)], rewrite)
+	}
+}
+
 // An Option affects the configuration of a console.
 type Option func(*config)
 
@@ -328,15 +948,40 @@ type config struct {
 	stdin          io.Reader
 	env            []string
 	verbosityValue verbosity
+	rewrites       []func(name string, args []string) (string, []string)
+	summary        *Summary
+	echoLogger     func(level, msg string)
+	plan           *Plan
+	allowedExits   []int
+	expand         expandMode
+	dirPerm        os.FileMode
+	execHook       func(*exec.Cmd) error
+	retryAttempts  int
+	retryBackoff   time.Duration
+	noColor        bool
+	nonInteractive bool
+	confirmDefault bool
+	assumeYes      bool
+	showDir        bool
 }
 
 func (c *config) Dir() string          { return c.dir }
 func (c *config) Env() []string        { return c.env }
 func (c *config) Stdout() io.Writer    { return c.stdout }
 func (c *config) Stderr() io.Writer    { return c.stderr }
-func (c *config) Stdin() io.Reader     { return c.stdin }
+func (c *config) Stdin() io.Reader     { return c.input() }
+func (c *config) IsVerbose() bool      { return c.verbosityValue.Verbose() }
+func (c *config) IsQuiet() bool        { return c.verbosityValue.Quiet() }
+func (c *config) IsSilent() bool       { return c.verbosityValue.Silent() }
 func (c *config) verbosity() verbosity { return c.verbosityValue }
 
+func (c *config) AbsDir() (string, error) {
+	if c.dir == `` {
+		return os.Getwd()
+	}
+	return filepath.Abs(c.dir)
+}
+
 // FormatCommand wraps AppendCommand to return a string.
 func FormatCommand(cmd *exec.Cmd) string {
 	return string(AppendCommand(make([]byte, 0, 256), cmd))
@@ -360,13 +1005,13 @@ func FormatEnv(env ...string) string {
 // AppendCommand appends the specified command in POSIX shell format, using AppendCommandPath, AppendArgs and AppendEnv.
 func AppendCommand(buf []byte, cmd *exec.Cmd) []byte {
 	if env := variableEnv(novelEnv(cmd.Env...)...); len(env) > 0 {
-		buf = appendEnv(buf, cmd.Env...)
+		buf = appendEnv(buf, env...)
 		buf = append(buf, ' ')
 	}
 
 	buf = AppendCommandPath(buf, cmd.Path)
 
-	if len(cmd.Args) > 0 {
+	if len(cmd.Args) > 1 {
 		buf = append(buf, ' ')
 		buf = AppendArgs(buf, cmd.Args[1:]...)
 	}
@@ -374,28 +1019,63 @@ func AppendCommand(buf []byte, cmd *exec.Cmd) []byte {
 	return buf
 }
 
-// AppendCommandPath appends the specified command path in POSIX shell format.  This will trim the command to just the filename if the
-// executable could be found in $PATH.
+// AppendCommandPath appends the specified command path in POSIX shell format.  This will trim the command to just the filename if
+// that filename resolves to the same executable using $PATH.
 func AppendCommandPath(buf []byte, path string) []byte {
+	return appendPOSIXValue(buf, shortCommandPath(path))
+}
+
+// shortCommandPath returns the filename of path if looking it up in $PATH would find the same executable, otherwise path.
+func shortCommandPath(path string) string {
 	name := filepath.Base(path)
-	if found, _ := exec.LookPath(name); found == path {
-		path = name
+	if name == path {
+		return path
+	}
+	found, err := exec.LookPath(name)
+	if err != nil {
+		return path // not in PATH, or PATH is empty, so the full path is needed to find it.
+	}
+	if filepath.Clean(found) != filepath.Clean(path) {
+		return path // another executable with the same name comes first in PATH.
 	}
-	return appendPOSIXValue(buf, path)
+	if !isExecutable(path) {
+		return path
+	}
+	return name
 }
 
 // AppendArgs appends the arguments in POSIX shell format -- this will leave arguments that have nonzero length and consist of
-// alphanumeric characters unchanged, but it will wrap and escape other text.
+// alphanumeric characters unchanged, but it will wrap and escape other text.  Empty arguments are rendered as an empty pair of single quotes.
 func AppendArgs(buf []byte, args ...string) []byte {
-	if len(args) == 0 {
-		return buf
-	}
-	for _, arg := range args {
+	for i, arg := range args {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
 		buf = appendPOSIXValue(buf, arg)
-		buf = append(buf, ' ')
 	}
-	// truncate off the trailing space.
-	return buf[: len(buf)-1 : cap(buf)]
+	return buf
+}
+
+// FormatArgsANSI wraps AppendArgsANSI to return a string.
+func FormatArgsANSI(args ...string) string {
+	return string(AppendArgsANSI(make([]byte, 0, 64), args...))
+}
+
+// AppendArgsANSI is like AppendArgs, but arguments containing control characters like newlines and tabs are rendered
+// using ANSI-C quoting, as in $'a\nb', so the result stays on one line.  This is supported by bash, zsh and ksh, but
+// not every POSIX shell.
+func AppendArgsANSI(buf []byte, args ...string) []byte {
+	for i, arg := range args {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		if hasControl(arg) {
+			buf = appendANSILiteral(buf, arg)
+		} else {
+			buf = appendPOSIXValue(buf, arg)
+		}
+	}
+	return buf
 }
 
 // AppendEnv appends the list of OS environment variables in POSIX shell format.  This will skip the shared prefix of env and os.Environ
@@ -416,7 +1096,7 @@ func variableEnv(env ...string) []string {
 	return vars
 }
 
-var rxValidEnv = regexp.MustCompile(`^[A-Za-z0-9_]=`)
+var rxValidEnv = regexp.MustCompile(`^[A-Za-z0-9_]+=`)
 
 // novelEnv returns env, skipping items already present in os.Environ()
 func novelEnv(env ...string) []string {
@@ -434,18 +1114,16 @@ func novelEnv(env ...string) []string {
 }
 
 func appendEnv(buf []byte, env ...string) []byte {
-	if len(env) == 0 {
-		return buf
-	}
-	for _, env := range env {
+	for i, env := range env {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
 		ofs := strings.IndexByte(env, '=')
 		buf = append(buf, env[:ofs]...)
 		buf = append(buf, '=')
-		buf = appendPOSIXValue(buf, env)
-		buf = append(buf, ' ')
+		buf = appendPOSIXValue(buf, env[ofs+1:])
 	}
-	// truncate off the trailing space.
-	return buf[: len(buf)-1 : cap(buf)]
+	return buf
 }
 
 func appendPOSIXValue(buf []byte, str string) []byte {
@@ -455,19 +1133,14 @@ func appendPOSIXValue(buf []byte, str string) []byte {
 	return appendPOSIXLiteral(buf, str)
 }
 
+// appendPOSIXLiteral appends str in single quotes, where nothing is special except the single quote itself, which must
+// be closed, escaped and reopened.
 func appendPOSIXLiteral(buf []byte, str string) []byte {
 	buf = append(buf, '\'')
 	for _, ch := range []byte(str) {
-		switch ch {
-		case '\r':
-			buf = append(buf, '\r')
-		case '\n':
-			buf = append(buf, '\n')
-		case '\t':
-			buf = append(buf, '\t')
-		case '\\', '\'':
-			buf = append(buf, '\\', ch)
-		default:
+		if ch == '\'' {
+			buf = append(buf, `'\''`...)
+		} else {
 			buf = append(buf, ch)
 		}
 	}
@@ -475,5 +1148,38 @@ func appendPOSIXLiteral(buf []byte, str string) []byte {
 	return buf
 }
 
+// appendANSILiteral appends str in $'...' quotes, escaping backslashes, single quotes and control characters.
+func appendANSILiteral(buf []byte, str string) []byte {
+	const hex = `0123456789abcdef`
+	buf = append(buf, '$', '\'')
+	for _, ch := range []byte(str) {
+		switch {
+		case ch == '\n':
+			buf = append(buf, '\\', 'n')
+		case ch == '\r':
+			buf = append(buf, '\\', 'r')
+		case ch == '\t':
+			buf = append(buf, '\\', 't')
+		case ch == '\\', ch == '\'':
+			buf = append(buf, '\\', ch)
+		case ch < 0x20 || ch == 0x7f:
+			buf = append(buf, '\\', 'x', hex[ch>>4], hex[ch&15])
+		default:
+			buf = append(buf, ch)
+		}
+	}
+	return append(buf, '\'')
+}
+
+// hasControl returns true if str contains an ASCII control character.
+func hasControl(str string) bool {
+	for _, ch := range []byte(str) {
+		if ch < 0x20 || ch == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
 var rxCmd = regexp.MustCompile(`^[^ \r\n\t*?[\]{}|&;<>'` + "`" + `\\$#=!]+$`)
 var rxValue = regexp.MustCompile(`^[^ \r\n\t*?[\]{}|&;<>'` + "`" + `\\$#!]+$`) // Note that = is okay here.
@@ -0,0 +1,752 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swdunlop/zugzug-go/zug"
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+	"github.com/swdunlop/zugzug-go/zug/worker"
+)
+
+// failTwice is a script that prints the number of the attempt, then fails unless it is the third attempt, counting
+// attempts in the file named by its first argument.
+const failTwice = `n=$(cat "$1" 2>/dev/null || echo 0); n=$((n+1)); echo $n >"$1"; echo "attempt $n"; [ $n -ge 3 ]`
+
+func TestRetry(t *testing.T) {
+	ctx, _, stderr := consoletest.New(console.Retry(3, 0))
+	ctx, summary := console.WithCommandSummary(ctx)
+	counter := filepath.Join(t.TempDir(), `counter`)
+	if err := console.Run(ctx, `sh`, `-c`, failTwice, `sh`, counter); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(stderr.String(), `-- retrying in`); got != 2 {
+		t.Errorf(`noted %v retries, want 2: %q`, got, stderr.String())
+	}
+	if summary.Succeeded() != 1 || len(summary.Failures()) != 0 {
+		t.Errorf(`got summary %q, want one success`, summary)
+	}
+
+	ctx, _, _ = consoletest.New(console.Retry(2, 0))
+	ctx, summary = console.WithCommandSummary(ctx)
+	counter = filepath.Join(t.TempDir(), `counter`)
+	err := console.Run(ctx, `sh`, `-c`, failTwice, `sh`, counter)
+	if err == nil || !strings.Contains(err.Error(), `after 2 attempts`) {
+		t.Errorf(`got %v, want an error after 2 attempts`, err)
+	}
+	if summary.Succeeded() != 0 || len(summary.Failures()) != 1 {
+		t.Errorf(`got summary %q, want one failure`, summary)
+	}
+}
+
+func TestRetryLines(t *testing.T) {
+	ctx, _, _ := consoletest.New(console.Retry(3, 0))
+	counter := filepath.Join(t.TempDir(), `counter`)
+	var lines []string
+	err := console.RunLines(ctx, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	}, `sh`, `-c`, failTwice, `sh`, counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`attempt 3`}; !reflect.DeepEqual(lines, want) {
+		t.Errorf(`got lines %q, want %q`, lines, want)
+	}
+}
+
+func TestRetryProgress(t *testing.T) {
+	ctx, _, _ := consoletest.New(console.Retry(3, 0))
+	counter := filepath.Join(t.TempDir(), `counter`)
+	var totals []int64
+	err := console.RunProgress(ctx, func(bytes int64) { totals = append(totals, bytes) },
+		`sh`, `-c`, failTwice, `sh`, counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(len("attempt 3\n")); len(totals) == 0 || totals[len(totals)-1] != want {
+		t.Errorf(`got progress %v, want a final total of %v`, totals, want)
+	}
+}
+
+// recordCommands returns an ExecHook option that appends the arguments of each command to ran instead of running it.
+func recordCommands(ran *[][]string) console.Option {
+	return console.ExecHook(func(cmd *exec.Cmd) error {
+		*ran = append(*ran, cmd.Args)
+		return nil
+	})
+}
+
+func TestRewriteCommand(t *testing.T) {
+	var ran [][]string
+	ctx, _, stderr := consoletest.New(recordCommands(&ran),
+		console.RewriteCommand(func(name string, args []string) (string, []string) {
+			if name == `docker` {
+				name = `podman`
+			}
+			return name, args
+		}),
+		console.RewriteCommand(func(name string, args []string) (string, []string) {
+			return name, append([]string{`--remote`}, args...)
+		}),
+	)
+	if err := console.Run(ctx, `docker`, `ps`); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{`podman`, `--remote`, `ps`}}; !reflect.DeepEqual(ran, want) {
+		t.Errorf(`ran %q, want %q`, ran, want)
+	}
+	if got := stderr.String(); got != ">> podman --remote ps\n" {
+		t.Errorf(`echoed %q`, got)
+	}
+}
+
+func TestBrokenPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := r.Close(); err != nil { // like head exiting after reading enough.
+		t.Fatal(err)
+	}
+	ctx, _, _ := consoletest.New(console.Stdout(w))
+	if err := console.Print(ctx, `hello`); !errors.Is(err, console.ErrBrokenPipe) {
+		t.Errorf(`Print returned %v, want ErrBrokenPipe`, err)
+	}
+	if err := console.Printf(ctx, "%v\n", `hello`); !errors.Is(err, console.ErrBrokenPipe) {
+		t.Errorf(`Printf returned %v, want ErrBrokenPipe`, err)
+	}
+}
+
+func TestTruncateLines(t *testing.T) {
+	ctx, stdout, _ := consoletest.New(console.TruncateLines(3))
+	if err := console.Print(ctx, `abcdefgh`); err != nil {
+		t.Fatal(err)
+	}
+	if got := stdout.String(); got != "abcdefgh\n" {
+		t.Errorf(`truncated %q, which is not a terminal`, got)
+	}
+	out, err := console.Eval(ctx, `echo`, `abcdefgh`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "abcdefgh\n" {
+		t.Errorf(`Eval returned %q, want the whole line`, out)
+	}
+}
+
+func TestExpect(t *testing.T) {
+	ctx, _, _ := consoletest.New()
+	if err := console.Expect(ctx, `hello world`, `echo`, `  hello world  `); err != nil {
+		t.Errorf(`got %v for matching output`, err)
+	}
+	err := console.Expect(ctx, `goodbye`, `echo`, `hello`)
+	if err == nil || err.Error() != `expected "echo" to output "goodbye", got "hello"` {
+		t.Errorf(`got %v for mismatching output`, err)
+	}
+	if err := console.ExpectMatch(ctx, regexp.MustCompile(`^go1\.`), `echo`, `go1.21.0`); err != nil {
+		t.Errorf(`got %v for matching a regexp`, err)
+	}
+	err = console.ExpectMatch(ctx, regexp.MustCompile(`^go1\.`), `echo`, `devel`)
+	if err == nil || !strings.Contains(err.Error(), `to output a match for "^go1\\.", got "devel"`) {
+		t.Errorf(`got %v for not matching a regexp`, err)
+	}
+}
+
+func TestCommandSummary(t *testing.T) {
+	ctx, _, _ := consoletest.New()
+	ctx, summary := console.WithCommandSummary(ctx)
+	_ = console.Run(ctx, `true`)
+	_ = console.Run(ctx, `false`)
+	_, _ = console.Eval(ctx, `echo`, `hello`)
+	_ = console.Run(ctx, `sh`, `-c`, `exit 3`)
+
+	if got := summary.Succeeded(); got != 2 {
+		t.Errorf(`%v commands succeeded, want 2`, got)
+	}
+	var failed []string
+	for _, failure := range summary.Failures() {
+		if failure.Err == nil {
+			t.Errorf(`failure of %q has no error`, failure.Command)
+		}
+		failed = append(failed, failure.Command)
+	}
+	if want := []string{`false`, `sh -c 'exit 3'`}; !reflect.DeepEqual(failed, want) {
+		t.Errorf(`got failures %q, want %q`, failed, want)
+	}
+	if got, want := summary.String(), `2 commands succeeded, 2 failed`; got != want {
+		t.Errorf(`got %q, want %q`, got, want)
+	}
+
+	// The buffers from consoletest are not safe for concurrent commands, so these discard their output.
+	ctx = console.With(ctx, console.Stdin(nil), console.Stdout(io.Discard), console.Stderr(io.Discard))
+	ctx, summary = console.WithCommandSummary(ctx)
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			if i%2 == 0 {
+				_ = console.Run(ctx, `true`)
+			} else {
+				_ = console.Run(ctx, `false`)
+			}
+		}(i)
+	}
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+	if summary.Succeeded() != 4 || len(summary.Failures()) != 4 {
+		t.Errorf(`got %q from concurrent commands, want 4 of each`, summary)
+	}
+}
+
+func TestRunEnv(t *testing.T) {
+	ctx, stdout, stderr := consoletest.New(console.Env(`FOO=console`, `BAR=kept`))
+	if err := console.RunEnv(ctx, []string{`FOO=bar`}, `sh`, `-c`, `echo "$FOO $BAR"`); err != nil {
+		t.Fatal(err)
+	}
+	if got := stdout.String(); got != "bar kept\n" {
+		t.Errorf(`got %q from RunEnv, want "bar kept"`, got)
+	}
+	if !strings.Contains(stderr.String(), `FOO=bar`) {
+		t.Errorf(`the command logged does not show FOO=bar: %q`, stderr.String())
+	}
+
+	got, err := console.EvalEnv(ctx, []string{`FOO=eval`}, `sh`, `-c`, `echo "$FOO"`)
+	if err != nil || got != "eval\n" {
+		t.Errorf(`got %q, %v from EvalEnv, want "eval"`, got, err)
+	}
+
+	got, err = console.Eval(ctx, `sh`, `-c`, `echo "$FOO"`)
+	if err != nil || got != "console\n" {
+		t.Errorf(`got %q, %v from a later command, want "console"`, got, err)
+	}
+}
+
+func TestRunIfStale(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, `target`)
+	for _, tc := range []struct {
+		name  string
+		mtime time.Time // zero if target is missing
+		runs  bool
+	}{
+		{`missing`, time.Time{}, true},
+		{`fresh`, time.Now().Add(-time.Minute), false},
+		{`stale`, time.Now().Add(-2 * time.Hour), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_ = os.Remove(target)
+			if !tc.mtime.IsZero() {
+				if err := os.WriteFile(target, nil, 0o666); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(target, tc.mtime, tc.mtime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var ran [][]string
+			ctx, _, _ := consoletest.New(console.Dir(dir), recordCommands(&ran))
+			if err := console.RunIfStale(ctx, `target`, time.Hour, `touch`, `target`); err != nil {
+				t.Fatal(err)
+			}
+			if runs := len(ran) > 0; runs != tc.runs {
+				t.Errorf(`ran %q, want runs to be %v`, ran, tc.runs)
+			}
+		})
+	}
+}
+
+func TestDedupeLines(t *testing.T) {
+	ctx, stdout, _ := consoletest.New(console.DedupeLines())
+	script := `echo building; echo building; echo building; echo done`
+	if err := console.Run(ctx, `sh`, `-c`, script); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "building (x3)\ndone\n"; got != want {
+		t.Errorf(`got %q from Run, want %q`, got, want)
+	}
+	got, err := console.Eval(ctx, `sh`, `-c`, script)
+	if want := "building\nbuilding\nbuilding\ndone\n"; err != nil || got != want {
+		t.Errorf(`got %q, %v from Eval, want %q`, got, err, want)
+	}
+}
+
+func TestTempDir(t *testing.T) {
+	ctx, _, _ := consoletest.New()
+	ctx, cleanup, err := console.TempDir(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	dir := console.From(ctx).Dir()
+	prefix := strings.TrimSuffix(filepath.Base(os.Args[0]), `.exe`) + `-`
+	if !strings.HasPrefix(filepath.Base(dir), prefix) {
+		t.Errorf(`%q is not named after %q`, dir, prefix)
+	}
+	got, err := console.Eval(ctx, `pwd`, `-P`)
+	if want, _ := filepath.EvalSymlinks(dir); err != nil || strings.TrimSpace(got) != want {
+		t.Errorf(`commands ran in %q, %v, want %q`, got, err, want)
+	}
+	cleanup()
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf(`%q still exists after cleanup: %v`, dir, err)
+	}
+	cleanup() // is safe to call again.
+}
+
+func TestEchoLogger(t *testing.T) {
+	var logged []string
+	ctx, _, stderr := consoletest.New(console.EchoLogger(func(level, msg string) {
+		logged = append(logged, level+`: `+msg)
+	}))
+	_ = console.Run(ctx, `true`)
+	if err := console.Run(ctx, `false`); err == nil {
+		t.Fatal(`false did not fail`)
+	}
+	if len(logged) != 3 || logged[0] != `info: true` || logged[1] != `info: false` ||
+		!strings.HasPrefix(logged[2], `error: `) {
+		t.Errorf(`logged %q, want the echoes at info and the failure at error`, logged)
+	}
+	if stderr.Len() > 0 {
+		t.Errorf(`wrote %q to stderr despite the EchoLogger`, stderr.String())
+	}
+}
+
+func TestAbsDir(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs := t.TempDir()
+	for _, tc := range []struct {
+		dir, want string
+	}{
+		{``, wd},
+		{`testdata/sub`, filepath.Join(wd, `testdata`, `sub`)},
+		{abs, abs},
+	} {
+		ctx, _, _ := consoletest.New(console.Dir(tc.dir))
+		c := console.From(ctx)
+		got, err := c.AbsDir()
+		if err != nil || got != tc.want {
+			t.Errorf(`%q: got %q, %v, want %q`, tc.dir, got, err, tc.want)
+		}
+		if c.Dir() != tc.dir {
+			t.Errorf(`%q: Dir returned %q`, tc.dir, c.Dir())
+		}
+	}
+}
+
+// countWrites is a writer that counts calls to Write, even those with no bytes.
+type countWrites int
+
+func (n *countWrites) Write(p []byte) (int, error) { *n++; return len(p), nil }
+
+func TestResetTees(t *testing.T) {
+	var outTee, errTee countWrites
+	ctx := console.With(context.Background(), console.TeeStdout(&outTee), console.TeeStderr(&errTee))
+	_ = console.Printf(ctx, ``)
+	_ = console.Errorf(ctx, ``)
+	if outTee != 1 || errTee != 1 {
+		t.Fatalf(`tees saw %v and %v writes, want 1 each`, outTee, errTee)
+	}
+
+	ctx = console.With(ctx, console.ResetStdout(), console.ResetStderr())
+	_ = console.Printf(ctx, ``) // writes nothing to the real stdout and stderr.
+	_ = console.Errorf(ctx, ``)
+	if outTee != 1 || errTee != 1 {
+		t.Errorf(`tees saw %v and %v writes after the reset, want 1 each`, outTee, errTee)
+	}
+	def := console.From(context.Background())
+	if c := console.From(ctx); c.Stdout() != def.Stdout() || c.Stderr() != def.Stderr() {
+		t.Error(`the reset did not restore the default writers`)
+	}
+}
+
+func TestMergeStderr(t *testing.T) {
+	ctx, stdout, stderr := consoletest.New(console.MergeStderr())
+	_ = console.Print(ctx, `first`)
+	_ = console.PrintError(ctx, `second`)
+	_ = console.Print(ctx, `third`)
+	if err := console.Run(ctx, `false`); err == nil {
+		t.Fatal(`false did not fail`)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 5 || lines[0] != `first` || lines[1] != `second` || lines[2] != `third` ||
+		lines[3] != `>> false` || !strings.HasPrefix(lines[4], `!! `) {
+		t.Errorf(`got %q, want everything on stdout in order`, lines)
+	}
+	if stderr.Len() > 0 {
+		t.Errorf(`wrote %q to stderr`, stderr.String())
+	}
+}
+
+func TestRunProgress(t *testing.T) {
+	ctx, stdout, _ := consoletest.New()
+	var totals []int64
+	err := console.RunProgress(ctx, func(bytes int64) { totals = append(totals, bytes) },
+		`head`, `-c`, `100000`, `/dev/zero`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(totals) == 0 || totals[len(totals)-1] != 100000 {
+		t.Fatalf(`got progress %v, want a final total of 100000`, totals)
+	}
+	for i := 1; i < len(totals); i++ {
+		if totals[i] < totals[i-1] {
+			t.Errorf(`progress went backwards: %v`, totals)
+		}
+	}
+	if stdout.Len() != 100000 {
+		t.Errorf(`relayed %v bytes to stdout, want 100000`, stdout.Len())
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `build.log`)
+	if err := os.WriteFile(path, []byte("stale\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	logFile, closer, err := console.LogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, stdout, stderr := consoletest.New(logFile)
+	if err := console.Run(ctx, `sh`, `-c`, `echo to-stdout; echo to-stderr >&2`); err != nil {
+		t.Fatal(err)
+	}
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`to-stdout`, `to-stderr`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf(`log file %q does not contain %q`, data, want)
+		}
+	}
+	if strings.Contains(string(data), `stale`) {
+		t.Errorf(`log file %q was not truncated`, data)
+	}
+	if !strings.Contains(stdout.String(), `to-stdout`) || !strings.Contains(stderr.String(), `to-stderr`) {
+		t.Errorf(`the original output was lost: %q, %q`, stdout.String(), stderr.String())
+	}
+}
+
+func TestFilter(t *testing.T) {
+	script := `echo ok 1; echo ERROR 2; printf 'ok '; sleep 0.01; echo 3; echo ERROR 4`
+	ctx, stdout, _ := consoletest.New(console.FilterOut(regexp.MustCompile(`ERROR`)))
+	if err := console.Run(ctx, `sh`, `-c`, script); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "ok 1\nok 3\n"; got != want {
+		t.Errorf(`got %q from FilterOut, want %q`, got, want)
+	}
+
+	ctx, stdout, _ = consoletest.New(console.Filter(regexp.MustCompile(`ERROR`)), console.Indent(`  `))
+	if err := console.Run(ctx, `sh`, `-c`, script); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "  ERROR 2\n  ERROR 4\n"; got != want {
+		t.Errorf(`got %q from Filter with Indent, want %q`, got, want)
+	}
+}
+
+// syncBuffer is a strings.Builder that is safe for concurrent writes.
+type syncBuffer struct {
+	control sync.Mutex
+	strings.Builder
+}
+
+func (buf *syncBuffer) Write(p []byte) (int, error) {
+	buf.control.Lock()
+	defer buf.control.Unlock()
+	return buf.Builder.Write(p)
+}
+
+func TestStartLabeled(t *testing.T) {
+	var stdout syncBuffer
+	ctx := worker.With(context.Background(), worker.EmptyState())
+	ctx = console.With(ctx, console.Stdout(&stdout))
+	task := func(name string) zug.Task {
+		return zug.Alias(name, zug.New(func(ctx context.Context) error {
+			return console.Print(ctx, `hello from `+name)
+		}))
+	}
+	if err := console.StartLabeled(ctx, task(`build`), task(`test`)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"[build] hello from build\n", "[test] hello from test\n"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf(`%q does not contain %q`, stdout.String(), want)
+		}
+	}
+}
+
+func TestRunLinesStreaming(t *testing.T) {
+	ctx, _, _ := consoletest.New()
+	started := time.Now()
+	var arrivals []time.Duration
+	err := console.RunLines(ctx, func(line string) error {
+		arrivals = append(arrivals, time.Since(started))
+		return nil
+	}, `sh`, `-c`, `for i in 1 2 3; do echo $i; sleep 0.2; done`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(arrivals) != 3 || arrivals[2]-arrivals[0] < 300*time.Millisecond {
+		t.Errorf(`lines arrived at %v, want them to arrive as they were written`, arrivals)
+	}
+
+	errStop := errors.New(`stop`)
+	started = time.Now()
+	err = console.RunLines(ctx, func(line string) error { return errStop },
+		`sh`, `-c`, `echo first; exec sleep 10`)
+	if !errors.Is(err, errStop) {
+		t.Errorf(`got %v, want the error from the callback`, err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf(`took %v, the command was not killed`, elapsed)
+	}
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	started = time.Now()
+	err = console.RunLines(cancelCtx, func(line string) error { cancel(); return nil },
+		`sh`, `-c`, `echo first; exec sleep 10`)
+	if err == nil {
+		t.Error(`a canceled command did not fail`)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf(`took %v, the command was not killed when the context was canceled`, elapsed)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		script string
+		code   int
+		ok     bool
+	}{
+		{`exit 0`, 0, false},
+		{`exit 3`, 3, true},
+	} {
+		ctx, _, _ := consoletest.New()
+		err := console.Run(ctx, `sh`, `-c`, tc.script)
+		if code, ok := console.ExitCode(err); code != tc.code || ok != tc.ok {
+			t.Errorf(`%q: ExitCode returned %v, %v, want %v, %v`, tc.script, code, ok, tc.code, tc.ok)
+		}
+		code, err := console.RunCode(ctx, `sh`, `-c`, tc.script)
+		if err != nil || code != tc.code {
+			t.Errorf(`%q: RunCode returned %v, %v, want %v`, tc.script, code, err, tc.code)
+		}
+	}
+
+	ctx, _, _ := consoletest.New()
+	err := console.Run(ctx, `zugzug-bogus-tool`)
+	if code, ok := console.ExitCode(err); err == nil || ok || code != 0 {
+		t.Errorf(`got %v, %v for %v, want no exit code`, code, ok, err)
+	}
+	if _, err := console.RunCode(ctx, `zugzug-bogus-tool`); err == nil {
+		t.Error(`RunCode did not report a missing command`)
+	}
+}
+
+func TestAllowExit(t *testing.T) {
+	input := filepath.Join(t.TempDir(), `input`)
+	if err := os.WriteFile(input, []byte("alpha\nbeta\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	ctx, _, _ := consoletest.New()
+	if err := console.Run(ctx, `grep`, `gamma`, input); err == nil {
+		t.Fatal(`grep without a match did not fail`)
+	}
+
+	ctx, _, stderr := consoletest.New(console.AllowExit(1))
+	if err := console.Run(ctx, `grep`, `gamma`, input); err != nil {
+		t.Errorf(`grep without a match failed despite AllowExit: %v`, err)
+	}
+	if !strings.Contains(stderr.String(), `-- exit status 1 allowed`) {
+		t.Errorf(`the exit code was not noted: %q`, stderr.String())
+	}
+	if out, err := console.Eval(ctx, `grep`, `beta`, input); err != nil || out != "beta\n" {
+		t.Errorf(`got %q, %v from a match`, out, err)
+	}
+	if err := console.Run(ctx, `grep`, `beta`, `/nonexistent`); err == nil {
+		t.Error(`exit code 2 was allowed`)
+	}
+}
+
+func TestFailurePrefix(t *testing.T) {
+	ctx, _, stderr := consoletest.New()
+	_ = console.PrintFailure(ctx, `top-level failure`)
+	_ = console.PrintFailuref(ctx, `failure %d`, 2)
+	_ = console.Run(ctx, `sh`, `-c`, `exit 3`)
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	want := []string{`!! top-level failure`, `!! failure 2`, `>> sh -c 'exit 3'`, `!! `}
+	if len(lines) != len(want) {
+		t.Fatalf(`got %q, want %v lines`, lines, len(want))
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, want[i]) {
+			t.Errorf(`line %v is %q, want it to start with %q`, i, line, want[i])
+		}
+	}
+	if strings.Contains(stderr.String(), "\x1b[") {
+		t.Errorf(`wrote color codes to a buffer: %q`, stderr.String())
+	}
+}
+
+func TestShowDir(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := t.TempDir()
+	for _, tc := range []struct {
+		options []console.Option
+		want    string
+	}{
+		{[]console.Option{console.ShowDir()}, ">> true\n"},
+		{[]console.Option{console.ShowDir(), console.Dir(wd)}, ">> true\n"},
+		{[]console.Option{console.ShowDir(), console.Dir(`.`)}, ">> true\n"},
+		{[]console.Option{console.ShowDir(), console.Dir(other)}, ">> (in " + console.FormatArgs(other) + ") true\n"},
+		{[]console.Option{console.Dir(other)}, ">> true\n"},
+	} {
+		ctx, _, stderr := consoletest.New(tc.options...)
+		if err := console.Run(ctx, `true`); err != nil {
+			t.Fatal(err)
+		}
+		if stderr.String() != tc.want {
+			t.Errorf(`got %q, want %q`, stderr.String(), tc.want)
+		}
+	}
+}
+
+func TestFormatCommandPath(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	for _, path := range []string{filepath.Join(first, `tool`), filepath.Join(second, `tool`), filepath.Join(second, `other`)} {
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv(`PATH`, first)
+	for _, tc := range []struct {
+		path, want string
+	}{
+		{filepath.Join(first, `tool`), `tool`},                             // resolves to itself using PATH.
+		{filepath.Join(second, `other`), filepath.Join(second, `other`)},   // not in PATH.
+		{filepath.Join(second, `tool`), filepath.Join(second, `tool`)},     // PATH finds another tool first.
+		{filepath.Join(first, `missing`), filepath.Join(first, `missing`)}, // does not exist.
+		{`tool`, `tool`},
+	} {
+		if got := console.FormatCommandPath(tc.path); got != tc.want {
+			t.Errorf(`%q: got %q, want %q`, tc.path, got, tc.want)
+		}
+	}
+
+	t.Setenv(`PATH`, ``)
+	if path := filepath.Join(first, `tool`); console.FormatCommandPath(path) != path {
+		t.Errorf(`%q was trimmed without a PATH`, path)
+	}
+}
+
+func TestFormatEmptyArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, ``},
+		{[]string{``}, `''`},
+		{[]string{``, ``}, `'' ''`},
+		{[]string{`a`, ``, `b`}, `a '' b`},
+		{[]string{`a`, ``}, `a ''`},
+	} {
+		if got := console.FormatArgs(tc.args...); got != tc.want {
+			t.Errorf(`%q: got %s, want %s`, tc.args, got, tc.want)
+		}
+		cmd := &exec.Cmd{Path: `echo`, Args: append([]string{`echo`}, tc.args...)}
+		if want := strings.TrimSpace(`echo ` + tc.want); console.FormatCommand(cmd) != want {
+			t.Errorf(`%q: got %s, want %s`, tc.args, console.FormatCommand(cmd), want)
+		}
+	}
+}
+
+func TestFormatArgsANSI(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"a\tb"}, `$'a\tb'`},
+		{[]string{"line 1\nline 2", `plain`}, `$'line 1\nline 2' plain`},
+		{[]string{"it's\r\n"}, `$'it\'s\r\n'`},
+		{[]string{"back\\slash\x1b[0m"}, `$'back\\slash\x1b[0m'`},
+		{[]string{`no controls`, ``}, `'no controls' ''`},
+	} {
+		got := console.FormatArgsANSI(tc.args...)
+		if got != tc.want {
+			t.Errorf(`%q: got %s, want %s`, tc.args, got, tc.want)
+		}
+		if strings.ContainsAny(got, "\n\t\r") {
+			t.Errorf(`%q: %q is not a single line`, tc.args, got)
+		}
+		if _, err := exec.LookPath(`bash`); err != nil {
+			continue
+		}
+		out, err := exec.Command(`bash`, `-c`, `printf '%s\0' `+got).Output()
+		if want := strings.Join(tc.args, "\x00") + "\x00"; err != nil || string(out) != want {
+			t.Errorf(`%q: bash read %s as %q, %v`, tc.args, got, out, err)
+		}
+	}
+}
+
+func TestStdin(t *testing.T) {
+	ctx, _, _ := consoletest.New(consoletest.Stdin("hello\n"))
+	data, err := io.ReadAll(console.From(ctx).Stdin())
+	if err != nil || string(data) != "hello\n" {
+		t.Errorf(`got %q, %v from Stdin`, data, err)
+	}
+	if console.From(context.Background()).Stdin() != os.Stdin {
+		t.Error(`the default console does not read os.Stdin`)
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	for _, tc := range []struct {
+		name                   string
+		options                []console.Option
+		verbose, quiet, silent bool
+	}{
+		{`normal`, nil, false, false, false},
+		{`verbose`, []console.Option{console.Verbose()}, true, false, false},
+		{`quiet`, []console.Option{console.Quiet()}, false, true, false},
+		{`silent`, []console.Option{console.Silent()}, false, false, true},
+		{`last wins`, []console.Option{console.Silent(), console.Verbose()}, true, false, false},
+	} {
+		ctx, _, _ := consoletest.New(tc.options...)
+		c := console.From(ctx)
+		if c.IsVerbose() != tc.verbose || c.IsQuiet() != tc.quiet || c.IsSilent() != tc.silent {
+			t.Errorf(`%v: got verbose %v, quiet %v, silent %v`, tc.name, c.IsVerbose(), c.IsQuiet(), c.IsSilent())
+		}
+	}
+}
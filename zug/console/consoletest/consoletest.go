@@ -0,0 +1,33 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package consoletest provides a console that captures output for testing tasks.
+package consoletest
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+)
+
+// New returns a context with a console that writes stdout and stderr to the returned buffers, and reads stdin from
+// an empty reader.  Options, like console.Env or Stdin, are applied after the buffers, so they can replace them.
+func New(options ...console.Option) (context.Context, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	ctx := console.With(context.Background(),
+		console.Stdout(&stdout),
+		console.Stderr(&stderr),
+		console.Stdin(strings.NewReader(``)),
+	)
+	return console.With(ctx, options...), &stdout, &stderr
+}
+
+// Stdin returns an option that provides input as stdin for the console.
+func Stdin(input string) console.Option {
+	return console.Stdin(strings.NewReader(input))
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package consoletest_test
+
+import (
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestNew(t *testing.T) {
+	ctx, stdout, stderr := consoletest.New()
+	_ = console.Print(ctx, `to stdout`)
+	_ = console.PrintError(ctx, `to stderr`)
+	if stdout.String() != "to stdout\n" || stderr.String() != "to stderr\n" {
+		t.Errorf(`captured %q and %q`, stdout.String(), stderr.String())
+	}
+	if out, err := console.Eval(ctx, `cat`); err != nil || out != `` {
+		t.Errorf(`got %q, %v, want empty stdin`, out, err)
+	}
+}
+
+func TestStdinAndEnv(t *testing.T) {
+	ctx, stdout, _ := consoletest.New(consoletest.Stdin("hello\n"), console.FullEnv([]string{`GREETING=hi`}))
+	if err := console.Run(ctx, `sh`, `-c`, `read line; echo "$GREETING $line"`); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "hi hello\n" {
+		t.Errorf(`got %q, want the injected stdin and env`, stdout.String())
+	}
+}
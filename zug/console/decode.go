@@ -0,0 +1,36 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DecodeStdin reads all of stdin and decodes it as JSON into v.
+func DecodeStdin(ctx context.Context, v any) error {
+	return DecodeStdinWith(ctx, v, json.Unmarshal)
+}
+
+// DecodeStdinWith reads all of stdin and decodes it into v using the provided function, such as yaml.Unmarshal.  Empty
+// input is an error.
+func DecodeStdinWith(ctx context.Context, v any, decode func(data []byte, v any) error) error {
+	data, err := io.ReadAll(from(ctx).input())
+	if err != nil {
+		return fmt.Errorf(`%w while reading stdin`, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fmt.Errorf(`expected input on stdin, got nothing`)
+	}
+	if err := decode(data, v); err != nil {
+		return fmt.Errorf(`%w while decoding stdin`, err)
+	}
+	return nil
+}
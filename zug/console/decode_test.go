@@ -0,0 +1,40 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestDecodeStdin(t *testing.T) {
+	for _, tc := range []struct {
+		input, wantErr string
+		want           int
+	}{
+		{`{"port": 8080}`, ``, 8080},
+		{"  \n", `expected input on stdin`, 0},
+		{`{"port": `, `while decoding stdin`, 0},
+	} {
+		var config struct {
+			Port int `json:"port"`
+		}
+		ctx, _, _ := consoletest.New(consoletest.Stdin(tc.input))
+		err := console.DecodeStdin(ctx, &config)
+		switch {
+		case tc.wantErr == `` && err != nil:
+			t.Errorf(`%q: %v`, tc.input, err)
+		case tc.wantErr != `` && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf(`%q: got %v, want an error containing %q`, tc.input, err, tc.wantErr)
+		case config.Port != tc.want:
+			t.Errorf(`%q: got port %v, want %v`, tc.input, config.Port, tc.want)
+		}
+	}
+}
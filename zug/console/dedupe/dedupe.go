@@ -0,0 +1,105 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package dedupe provides a writer that collapses consecutive identical lines, like syslog.  Like indent, this is
+// largely safe for concurrency but lines that span writes from different goroutines may be mixed.
+package dedupe
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Writer returns a writer that holds back the most recent complete line until a different line is written or the
+// writer is flushed, so that repeats of that line can be written once with a suffix like " (x3)".  The writer has a
+// Flush method, which console.Flush calls.
+func Writer(w io.Writer) io.Writer {
+	return &writer{io: w}
+}
+
+type writer struct {
+	mu      sync.Mutex
+	io      io.Writer
+	partial []byte // bytes written after the last newline
+	last    []byte // the line being held back, without its newline
+	count   int    // the number of times last has been seen, zero if there is no line being held back
+}
+
+// Write implements io.Writer.
+func (wr *writer) Write(p []byte) (int, error) {
+	originalSz := len(p)
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	var buf []byte
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			wr.partial = append(wr.partial, p...)
+			break
+		}
+		wr.partial = append(wr.partial, p[:i]...)
+		p = p[i+1:]
+		if wr.count > 0 && bytes.Equal(wr.partial, wr.last) {
+			wr.count++
+		} else {
+			buf = wr.appendLast(buf)
+			wr.last, wr.partial = wr.partial, wr.last[:0]
+			wr.count = 1
+		}
+		wr.partial = wr.partial[:0]
+	}
+
+	if len(buf) == 0 {
+		return originalSz, nil
+	}
+	_, err := wr.io.Write(buf)
+	if err == nil {
+		return originalSz, nil
+	}
+	return 0, err
+}
+
+// Flush writes the line being held back, with its count, and any incomplete line, then flushes the underlying writer
+// if it has a Flush method.
+func (wr *writer) Flush() error {
+	if err := wr.flush(); err != nil {
+		return err
+	}
+	if f, ok := wr.io.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (wr *writer) flush() error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	buf := wr.appendLast(nil)
+	buf = append(buf, wr.partial...)
+	wr.count, wr.partial = 0, wr.partial[:0]
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := wr.io.Write(buf)
+	return err
+}
+
+// appendLast appends the line being held back to buf, if any, with a count if it repeated.
+func (wr *writer) appendLast(buf []byte) []byte {
+	switch wr.count {
+	case 0:
+		return buf
+	case 1:
+		buf = append(buf, wr.last...)
+	default:
+		buf = append(buf, wr.last...)
+		buf = append(buf, fmt.Sprintf(` (x%d)`, wr.count)...)
+	}
+	return append(buf, '\n')
+}
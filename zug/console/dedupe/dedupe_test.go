@@ -0,0 +1,46 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package dedupe_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console/dedupe"
+)
+
+func TestWriter(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{`distinct`, []string{"a\nb\nc\n"}, "a\nb\nc\n"},
+		{`repeated`, []string{"a\na\na\nb\n"}, "a (x3)\nb\n"},
+		{`repeated at end`, []string{"a\nb\nb\n"}, "a\nb (x2)\n"},
+		{`split`, []string{"a", "\na\n", "a", "\n"}, "a (x3)\n"},
+		{`runs`, []string{"a\na\nb\na\na\n"}, "a (x2)\nb\na (x2)\n"},
+		{`partial`, []string{"a\na\nb"}, "a (x2)\nb"},
+		{`empty lines`, []string{"\n\n\na\n"}, " (x3)\na\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := dedupe.Writer(&buf)
+			for _, p := range tc.writes {
+				if n, err := w.Write([]byte(p)); err != nil || n != len(p) {
+					t.Fatalf(`wrote %v of %v bytes: %v`, n, len(p), err)
+				}
+			}
+			if err := w.(interface{ Flush() error }).Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf(`got %q, want %q`, got, tc.want)
+			}
+		})
+	}
+}
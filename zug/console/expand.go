@@ -0,0 +1,81 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Expand replaces $VAR and ${VAR} in s with values from the console environment, not the process environment.
+// Undefined variables expand to an empty string, like a shell.
+func Expand(ctx context.Context, s string) string {
+	return os.Expand(s, func(key string) string {
+		value, _ := lookupEnv(from(ctx).env, key)
+		return value
+	})
+}
+
+// ExpandStrict is like Expand, but returns an error if s refers to an undefined variable.
+func ExpandStrict(ctx context.Context, s string) (string, error) {
+	return from(ctx).expandStrict(s)
+}
+
+// ExpandArgs causes Run, Eval and their variants to Expand each argument before the command is run.
+func ExpandArgs() Option {
+	return func(cfg *config) { cfg.expand = expandArgs }
+}
+
+// ExpandArgsStrict is like ExpandArgs, but commands fail without running if an argument refers to an undefined
+// variable.  The error is printed unless the console is quiet or silent, and is always returned.
+func ExpandArgsStrict() Option {
+	return func(cfg *config) { cfg.expand = expandArgsStrict }
+}
+
+type expandMode int
+
+const (
+	expandNothing expandMode = iota
+	expandArgs
+	expandArgsStrict
+)
+
+// expandArgs returns a copy of args expanded as specified by ExpandArgs or ExpandArgsStrict.
+func (cfg *config) expandArgs(args []string) ([]string, error) {
+	if cfg.expand == expandNothing {
+		return args, nil
+	}
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		if cfg.expand == expandArgsStrict {
+			var err error
+			expanded[i], err = cfg.expandStrict(arg)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		expanded[i] = os.Expand(arg, func(key string) string {
+			value, _ := lookupEnv(cfg.env, key)
+			return value
+		})
+	}
+	return expanded, nil
+}
+
+func (cfg *config) expandStrict(s string) (string, error) {
+	var err error
+	ret := os.Expand(s, func(key string) string {
+		value, ok := lookupEnv(cfg.env, key)
+		if !ok && err == nil {
+			err = fmt.Errorf(`undefined variable %q in %q`, key, s)
+		}
+		return value
+	})
+	return ret, err
+}
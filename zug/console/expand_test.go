@@ -0,0 +1,76 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console_test
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestExpand(t *testing.T) {
+	ctx, _, _ := consoletest.New(console.FullEnv([]string{`NAME=zug`}))
+	for _, tc := range []struct{ in, want string }{
+		{`hello $NAME`, `hello zug`},
+		{`hello ${NAME}zug`, `hello zugzug`},
+		{`hello $UNDEFINED.`, `hello .`},
+	} {
+		if got := console.Expand(ctx, tc.in); got != tc.want {
+			t.Errorf(`Expand(%q) = %q, want %q`, tc.in, got, tc.want)
+		}
+	}
+	if got, err := console.ExpandStrict(ctx, `${NAME}`); err != nil || got != `zug` {
+		t.Errorf(`got %q, %v for a defined variable`, got, err)
+	}
+	if _, err := console.ExpandStrict(ctx, `${UNDEFINED}`); err == nil {
+		t.Error(`strict expansion of an undefined variable did not fail`)
+	}
+}
+
+func TestExpandArgs(t *testing.T) {
+	var ran [][]string
+	hook := console.ExecHook(func(cmd *exec.Cmd) error {
+		ran = append(ran, cmd.Args[1:])
+		return nil
+	})
+	ctx, _, _ := consoletest.New(console.FullEnv([]string{`NAME=zug`}), hook, console.ExpandArgs())
+	if err := console.Run(ctx, `echo`, `$NAME`, `${NAME}s`, `$UNDEFINED`); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{`zug`, `zugs`, ``}}; !reflect.DeepEqual(ran, want) {
+		t.Errorf(`ran %q, want %q`, ran, want)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		verbosity []console.Option
+		printed   bool
+	}{
+		{`normal`, nil, true},
+		{`verbose`, []console.Option{console.Verbose()}, true},
+		{`quiet`, []console.Option{console.Quiet()}, false},
+		{`silent`, []console.Option{console.Silent()}, false},
+	} {
+		ran = nil
+		options := append([]console.Option{console.FullEnv(nil), hook, console.ExpandArgsStrict()}, tc.verbosity...)
+		ctx, _, stderr := consoletest.New(options...)
+		err := console.Run(ctx, `echo`, `$UNDEFINED`)
+		if err == nil || !strings.Contains(err.Error(), `undefined variable "UNDEFINED"`) {
+			t.Errorf(`%v: got %v, want an undefined variable error`, tc.name, err)
+		}
+		if len(ran) > 0 {
+			t.Errorf(`%v: ran %q despite the error`, tc.name, ran)
+		}
+		if printed := strings.Contains(stderr.String(), `UNDEFINED`); printed != tc.printed {
+			t.Errorf(`%v: printed is %v, want %v: %q`, tc.name, printed, tc.printed, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WriteFile writes data to name, resolving relative names against the console Dir.  The data is written to a
+// temporary file in the same directory then renamed over name, so readers never see a partial file.  If DirPerm was
+// specified, missing parent directories are created with that permission.
+func WriteFile(ctx context.Context, name string, data []byte, perm os.FileMode) error {
+	cfg := from(ctx)
+	name = cfg.path(name)
+	dir := filepath.Dir(name)
+	if cfg.dirPerm != 0 {
+		if err := os.MkdirAll(dir, cfg.dirPerm); err != nil {
+			return err
+		}
+	}
+	f, err := os.CreateTemp(dir, `.`+filepath.Base(name)+`-*`)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails harmlessly after the rename.
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// ReadFile reads name, resolving relative names against the console Dir.
+func ReadFile(ctx context.Context, name string) ([]byte, error) {
+	return os.ReadFile(from(ctx).path(name))
+}
+
+// Glob returns the names of files matching pattern, like filepath.Glob, but relative patterns are matched against the
+// console Dir and the names returned are relative to it.  A "**" path element matches zero or more directories, so
+// "**/*.go" matches every Go source in the tree.
+func Glob(ctx context.Context, pattern string) ([]string, error) {
+	cfg := from(ctx)
+	rel := ``
+	if filepath.IsAbs(pattern) {
+		rel = filepath.VolumeName(pattern) + string(filepath.Separator)
+		pattern = pattern[len(rel):]
+	}
+	elems := strings.Split(filepath.ToSlash(pattern), `/`)
+	for _, elem := range elems {
+		if _, err := filepath.Match(elem, ``); err != nil {
+			return nil, err
+		}
+	}
+	found := make(map[string]struct{})
+	cfg.glob(rel, elems, found)
+	matches := make([]string, 0, len(found))
+	for name := range found {
+		matches = append(matches, name)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// glob adds names under rel matching elems to found, ignoring I/O errors like filepath.Glob.
+func (cfg *config) glob(rel string, elems []string, found map[string]struct{}) {
+	if len(elems) == 0 {
+		if rel != `` {
+			found[rel] = struct{}{}
+		}
+		return
+	}
+	elem, rest := elems[0], elems[1:]
+	switch {
+	case elem == `` || elem == `.`:
+		cfg.glob(rel, rest, found)
+		return
+	case elem == `**`:
+		cfg.glob(rel, rest, found)
+	case !strings.ContainsAny(elem, `*?[\`):
+		name := filepath.Join(rel, elem)
+		if _, err := os.Lstat(cfg.path(name)); err == nil {
+			cfg.glob(name, rest, found)
+		}
+		return
+	}
+	dir := cfg.path(rel)
+	if dir == `` {
+		dir = `.`
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := filepath.Join(rel, entry.Name())
+		if elem == `**` {
+			if entry.IsDir() {
+				cfg.glob(name, elems, found)
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(elem, entry.Name()); !ok {
+			continue
+		}
+		if len(rest) == 0 || entry.IsDir() {
+			cfg.glob(name, rest, found)
+		}
+	}
+}
+
+// DirPerm causes WriteFile to create missing parent directories with the specified permission.
+func DirPerm(perm os.FileMode) Option {
+	return func(cfg *config) { cfg.dirPerm = perm }
+}
+
+// path resolves name relative to the console Dir.
+func (cfg *config) path(name string) string {
+	if cfg.dir == `` || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(cfg.dir, name)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, `config.json`)
+	if err := os.WriteFile(path, []byte(`old`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	old, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+
+	ctx, _, _ := consoletest.New(console.Dir(dir))
+	if err := console.WriteFile(ctx, `config.json`, []byte(`new`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != `new` {
+		t.Errorf(`got %q, %v, want "new"`, data, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf(`got %v, %v, want permission 0600`, info.Mode(), err)
+	}
+	if data, err := io.ReadAll(old); err != nil || string(data) != `old` {
+		t.Errorf(`the replaced file now reads %q, %v; it was rewritten in place`, data, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf(`found %v entries, want the temporary file removed`, len(entries))
+	}
+
+	if err := console.WriteFile(ctx, `sub/dir/file`, nil, 0o644); err == nil {
+		t.Error(`created parent directories without DirPerm`)
+	}
+	ctx, _, _ = consoletest.New(console.Dir(dir), console.DirPerm(0o755))
+	if err := console.WriteFile(ctx, `sub/dir/file`, []byte(`nested`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, `sub`, `dir`, `file`)); err != nil || string(data) != `nested` {
+		t.Errorf(`got %q, %v from the nested file`, data, err)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, `input.txt`)
+	if err := os.WriteFile(path, []byte(`hello`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	ctx, _, _ := consoletest.New(console.Dir(dir))
+	for _, name := range []string{`input.txt`, path} {
+		if data, err := console.ReadFile(ctx, name); err != nil || string(data) != `hello` {
+			t.Errorf(`%q: got %q, %v`, name, data, err)
+		}
+	}
+	if _, err := console.ReadFile(ctx, `missing.txt`); !os.IsNotExist(err) {
+		t.Errorf(`got %v for a missing file`, err)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{`main.go`, `README.md`, `cmd/tool/tool.go`, `cmd/tool/tool_test.go`, `internal/x.go`} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, _, _ := consoletest.New(console.Dir(dir))
+	for _, tc := range []struct {
+		pattern string
+		want    []string
+	}{
+		{`*.go`, []string{`main.go`}},
+		{`cmd/*/*.go`, []string{`cmd/tool/tool.go`, `cmd/tool/tool_test.go`}},
+		{`**/*.go`, []string{`cmd/tool/tool.go`, `cmd/tool/tool_test.go`, `internal/x.go`, `main.go`}},
+		{`**/*_test.go`, []string{`cmd/tool/tool_test.go`}},
+		{`*.txt`, []string{}},
+	} {
+		got, err := console.Glob(ctx, tc.pattern)
+		if err != nil {
+			t.Fatalf(`%q: %v`, tc.pattern, err)
+		}
+		for i := range got {
+			got[i] = filepath.ToSlash(got[i])
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf(`%q: got %q, want %q`, tc.pattern, got, tc.want)
+		}
+	}
+	if _, err := console.Glob(ctx, `[`); err == nil {
+		t.Error(`a malformed pattern did not fail`)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/swdunlop/zugzug-go/zug"
+)
+
+// FileTask returns a task that runs fn at most once, like zug.New, but skips it with zug.ErrSkip if every output
+// exists and no input is newer than it, like make.  Relative paths are resolved against the console Dir, and an input
+// that does not exist is an error.
+func FileTask(name string, outputs []string, inputs []string, fn func(context.Context) error) zug.NamedTask {
+	return zug.Alias(name, zug.New(func(ctx context.Context) error {
+		fresh, err := from(ctx).upToDate(outputs, inputs)
+		switch {
+		case err != nil:
+			return err
+		case fresh:
+			return fmt.Errorf(`%w, outputs are up to date`, zug.ErrSkip)
+		}
+		return fn(ctx)
+	}))
+}
+
+// upToDate returns true if every output exists and no input is newer than it.
+func (cfg *config) upToDate(outputs []string, inputs []string) (bool, error) {
+	var newest time.Time
+	for _, input := range inputs {
+		info, err := os.Stat(cfg.path(input))
+		if err != nil {
+			return false, err
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	for _, output := range outputs {
+		info, err := os.Stat(cfg.path(output))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return false, nil
+		case err != nil:
+			return false, err
+		case info.ModTime().Before(newest):
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/swdunlop/zugzug-go/zug"
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestFileTask(t *testing.T) {
+	dir := t.TempDir()
+	input, output := filepath.Join(dir, `main.go`), filepath.Join(dir, `main`)
+	touch := func(path string, age time.Duration) {
+		if err := os.WriteFile(path, nil, 0o666); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tc := range []struct {
+		name        string
+		input       time.Duration // age of the input
+		output      time.Duration // age of the output, or -1 if it is missing
+		runs, fails bool
+	}{
+		{`fresh`, 2 * time.Hour, time.Hour, false, false},
+		{`stale`, time.Hour, 2 * time.Hour, true, false},
+		{`missing output`, time.Hour, -1, true, false},
+		{`missing input`, -1, time.Hour, false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_ = os.Remove(input)
+			_ = os.Remove(output)
+			if tc.input >= 0 {
+				touch(input, tc.input)
+			}
+			if tc.output >= 0 {
+				touch(output, tc.output)
+			}
+			runs := false
+			task := console.FileTask(`build`, []string{output}, []string{input}, func(ctx context.Context) error {
+				runs = true
+				return nil
+			})
+			if task.TaskName() != `build` {
+				t.Errorf(`got name %q`, task.TaskName())
+			}
+			err := zug.Run(context.Background(), task)
+			if (err != nil) != tc.fails || runs != tc.runs {
+				t.Errorf(`got %v and runs %v, want fails %v and runs %v`, err, runs, tc.fails, tc.runs)
+			}
+		})
+	}
+
+	touch(input, 2*time.Hour)
+	touch(output, time.Hour)
+	task := console.FileTask(`build`, []string{output}, []string{input}, func(ctx context.Context) error { return nil })
+	if err := task.RunTask(context.Background()); !errors.Is(err, zug.ErrSkip) {
+		t.Errorf(`got %v, want the skip reported as ErrSkip`, err)
+	}
+
+	ctx, _, _ := consoletest.New(console.Dir(dir))
+	task = console.FileTask(`build`, []string{`main`}, []string{`main.go`}, func(ctx context.Context) error { return nil })
+	if err := task.RunTask(ctx); !errors.Is(err, zug.ErrSkip) {
+		t.Errorf(`got %v, want relative paths resolved against the console Dir`, err)
+	}
+}
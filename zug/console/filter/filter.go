@@ -0,0 +1,88 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package filter provides a writer that only passes lines that match a function.  Like indent, this is largely safe
+// for concurrency but lines that span writes from different goroutines may be mixed.
+package filter
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Writer returns a writer that buffers each line until it is complete, then writes it, with its newline, only if
+// match returns true for the line without its newline.  The writer has a Flush method, which console.Flush calls, that
+// checks an incomplete final line.
+func Writer(w io.Writer, match func(line []byte) bool) io.Writer {
+	return &writer{io: w, match: match}
+}
+
+type writer struct {
+	mu      sync.Mutex
+	io      io.Writer
+	match   func([]byte) bool
+	partial []byte // bytes written after the last newline
+}
+
+// Write implements io.Writer.
+func (wr *writer) Write(p []byte) (int, error) {
+	originalSz := len(p)
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	var buf []byte
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			wr.partial = append(wr.partial, p...)
+			break
+		}
+		wr.partial = append(wr.partial, p[:i]...)
+		p = p[i+1:]
+		if wr.match(wr.partial) {
+			buf = append(buf, wr.partial...)
+			buf = append(buf, '\n')
+		}
+		wr.partial = wr.partial[:0]
+	}
+
+	if len(buf) == 0 {
+		return originalSz, nil
+	}
+	_, err := wr.io.Write(buf)
+	if err == nil {
+		return originalSz, nil
+	}
+	return 0, err
+}
+
+// Flush writes the incomplete final line, if there is one and it matches, then flushes the underlying writer if it
+// has a Flush method.
+func (wr *writer) Flush() error {
+	if err := wr.flush(); err != nil {
+		return err
+	}
+	if f, ok := wr.io.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (wr *writer) flush() error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	if len(wr.partial) == 0 {
+		return nil
+	}
+	line := wr.partial
+	wr.partial = nil
+	if !wr.match(line) {
+		return nil
+	}
+	_, err := wr.io.Write(line)
+	return err
+}
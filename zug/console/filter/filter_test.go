@@ -0,0 +1,44 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package filter_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console/filter"
+)
+
+func TestWriter(t *testing.T) {
+	isError := func(line []byte) bool { return bytes.Contains(line, []byte(`ERROR`)) }
+	for _, tc := range []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{`mixed`, []string{"ok 1\nERROR 2\nok 3\nERROR 4\n"}, "ERROR 2\nERROR 4\n"},
+		{`split`, []string{"ok 1\nERR", "OR 2\nok", " 3\n"}, "ERROR 2\n"},
+		{`partial`, []string{"ok 1\nERROR 2"}, "ERROR 2"},
+		{`none`, []string{"ok 1\nok 2\n"}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := filter.Writer(&buf, isError)
+			for _, p := range tc.writes {
+				if n, err := w.Write([]byte(p)); err != nil || n != len(p) {
+					t.Fatalf(`wrote %v of %v bytes: %v`, n, len(p), err)
+				}
+			}
+			if err := w.(interface{ Flush() error }).Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf(`got %q, want %q`, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Require returns an error listing each of the named commands that cannot be found using the PATH from the console
+// environment, which lets a task fail early with a clear explanation.
+func Require(ctx context.Context, names ...string) error {
+	env := from(ctx).env
+	var missing []string
+	for _, name := range names {
+		if _, err := lookPath(name, env); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf(`required command %q was not found in PATH`, missing[0])
+	default:
+		return fmt.Errorf(`required commands %s were not found in PATH`, FormatArgs(missing...))
+	}
+}
+
+// lookPath is like exec.LookPath, but uses PATH (and PATHEXT on Windows) from env.  If env does not specify PATH,
+// this is the same as exec.LookPath.  Like exec.LookPath, a command found in a relative directory, including an empty
+// entry in PATH, which means the current directory, is returned with exec.ErrDot so it is not run by accident.
+func lookPath(name string, env []string) (string, error) {
+	path, ok := lookupEnv(env, `PATH`)
+	if !ok || strings.ContainsRune(name, filepath.Separator) || strings.ContainsRune(name, '/') {
+		return exec.LookPath(name)
+	}
+	exts := []string{``}
+	if runtime.GOOS == `windows` && filepath.Ext(name) == `` {
+		pathext, ok := lookupEnv(env, `PATHEXT`)
+		if !ok {
+			pathext = `.com;.exe;.bat;.cmd`
+		}
+		exts = filepath.SplitList(strings.ToLower(pathext))
+	}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == `` {
+			dir = `.`
+		}
+		for _, ext := range exts {
+			candidate := filepath.Join(dir, name+ext)
+			switch {
+			case !isExecutable(candidate):
+			case !filepath.IsAbs(candidate):
+				return candidate, &exec.Error{Name: name, Err: exec.ErrDot}
+			default:
+				return candidate, nil
+			}
+		}
+	}
+	return ``, &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
+
+// isExecutable returns true if path is a file that can be executed.  On Windows, any file is considered executable.
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return runtime.GOOS == `windows` || info.Mode()&0o111 != 0
+}
+
+// lookupEnv returns the last value for key in env, like the value used by exec.Cmd.
+func lookupEnv(env []string, key string) (string, bool) {
+	for i := len(env) - 1; i >= 0; i-- {
+		if strings.HasPrefix(env[i], key+`=`) {
+			return env[i][len(key)+1:], true
+		}
+	}
+	return ``, false
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestRequire(t *testing.T) {
+	if _, err := exec.LookPath(`go`); err != nil {
+		t.Skip(`go is not in PATH`)
+	}
+	ctx, _, _ := consoletest.New(console.FullEnv(os.Environ()))
+	if err := console.Require(ctx, `go`); err != nil {
+		t.Error(err)
+	}
+	err := console.Require(ctx, `go`, `zugzug-bogus-tool`, `zugzug-bogus-tool-2`)
+	if err == nil || !strings.Contains(err.Error(), `zugzug-bogus-tool zugzug-bogus-tool-2`) {
+		t.Errorf(`got %v, want an error listing both bogus tools`, err)
+	}
+}
+
+func TestRequireConsolePath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, `zugzug-tool`), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	for _, tc := range []struct {
+		path  string
+		found bool
+	}{
+		{dir, true},
+		{`.`, false}, // relative entries would run whatever is in the current directory.
+		{`:` + t.TempDir(), false},
+		{t.TempDir(), false},
+	} {
+		ctx, _, _ := consoletest.New(console.FullEnv([]string{`PATH=` + tc.path}))
+		if err := console.Require(ctx, `zugzug-tool`); (err == nil) != tc.found {
+			t.Errorf(`PATH=%v: got %v, want found to be %v`, tc.path, err, tc.found)
+		}
+	}
+}
+
+func TestConsolePath(t *testing.T) {
+	dir := t.TempDir()
+	shadow := filepath.Join(dir, `echo`)
+	if err := os.WriteFile(shadow, []byte("#!/bin/sh\necho shadowed\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ctx, _, stderr := consoletest.New(console.FullEnv([]string{`PATH=` + dir + `:` + os.Getenv(`PATH`)}))
+	got, err := console.Eval(ctx, `echo`, `hello`)
+	if err != nil || got != "shadowed\n" {
+		t.Errorf(`got %q, %v, want the shadowing echo`, got, err)
+	}
+	if want := console.FormatCommandPath(shadow) + ` hello`; !strings.Contains(stderr.String(), want) {
+		t.Errorf(`logged %q, want %q`, stderr.String(), want)
+	}
+
+	ctx, _, _ = consoletest.New(console.FullEnv(os.Environ()))
+	if got, err := console.Eval(ctx, `echo`, `hello`); err != nil || got != "hello\n" {
+		t.Errorf(`got %q, %v without the shadowing PATH`, got, err)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Confirm writes the question to stderr followed by " [y/N] ", then reads a line from stdin, returning true if the
+// answer starts with "y" or "Y".  An empty answer, or a console that is not interactive, returns the default from
+// ConfirmDefault instead, which is false unless specified.  A console is not interactive if it is silent,
+// NonInteractive was specified, or its stdin is a file that is not a terminal, like a pipe or /dev/null; readers
+// provided by Stdin that are not files are always read.  If AssumeYes was specified, Confirm returns true without
+// asking.
+func Confirm(ctx context.Context, question string) (bool, error) {
+	cfg := from(ctx)
+	switch {
+	case cfg.assumeYes:
+		return true, nil
+	case !cfg.interactive():
+		return cfg.confirmDefault, nil
+	}
+	if cfg.confirmDefault {
+		fmt.Fprint(cfg.stderr, question, ` [Y/n] `)
+	} else {
+		fmt.Fprint(cfg.stderr, question, ` [y/N] `)
+	}
+	answer, err := readLine(cfg.input())
+	switch {
+	case errors.Is(err, io.EOF):
+		return cfg.confirmDefault, nil
+	case err != nil:
+		return false, err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == `` {
+		return cfg.confirmDefault, nil
+	}
+	return strings.HasPrefix(answer, `y`) || strings.HasPrefix(answer, `Y`), nil
+}
+
+// Prompt writes the prompt to stderr, then reads a line from stdin and returns it without its line ending.  If the
+// console is not interactive, Prompt returns ErrNotInteractive without reading anything.
+func Prompt(ctx context.Context, prompt string) (string, error) {
+	cfg := from(ctx)
+	if !cfg.interactive() {
+		return ``, ErrNotInteractive
+	}
+	fmt.Fprint(cfg.stderr, prompt, ` `)
+	return readLine(cfg.input())
+}
+
+// PromptSecret is like Prompt, but if stdin is a terminal, the answer is not echoed, which is useful for passwords and
+// tokens.  The terminal is restored when the answer is read, or when ctx is canceled.
+//
+// Reading a terminal cannot be interrupted, so if ctx is canceled, PromptSecret returns at once but its read continues
+// in the background until a line is entered, which is then discarded.  Programs that cancel a secret prompt should exit
+// soon afterward rather than reading stdin again.
+func PromptSecret(ctx context.Context, prompt string) (string, error) {
+	cfg := from(ctx)
+	if !cfg.interactive() {
+		return ``, ErrNotInteractive
+	}
+	fmt.Fprint(cfg.stderr, prompt, ` `)
+	f, ok := cfg.input().(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return readLine(cfg.input())
+	}
+	defer fmt.Fprintln(cfg.stderr) // since the line ending was not echoed either.
+	fd := int(f.Fd())
+	state, err := term.GetState(fd)
+	if err != nil {
+		return ``, err
+	}
+	type result struct {
+		secret []byte
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		secret, err := term.ReadPassword(fd) // restores the terminal before returning.
+		done <- result{secret, err}
+	}()
+	select {
+	case r := <-done:
+		return string(r.secret), r.err
+	case <-ctx.Done():
+		_ = term.Restore(fd, state)
+		return ``, ctx.Err()
+	}
+}
+
+// ErrNotInteractive is returned by Prompt and PromptSecret when the console is not interactive, as described by Confirm.
+var ErrNotInteractive = errors.New(`console is not interactive`)
+
+// NonInteractive prevents Confirm and Prompt from reading stdin, for consoles where nobody is around to answer.
+func NonInteractive() Option {
+	return func(cfg *config) { cfg.nonInteractive = true }
+}
+
+// AssumeYes causes Confirm to return true without asking, for scripts that have already decided.
+func AssumeYes() Option {
+	return func(cfg *config) { cfg.assumeYes = true }
+}
+
+// ConfirmDefault specifies the answer Confirm returns for an empty answer or a console that is not interactive.
+func ConfirmDefault(answer bool) Option {
+	return func(cfg *config) { cfg.confirmDefault = answer }
+}
+
+func (cfg *config) interactive() bool {
+	if cfg.nonInteractive || cfg.verbosityValue == silentVerbosity {
+		return false
+	}
+	if f, ok := cfg.input().(*os.File); ok {
+		return term.IsTerminal(int(f.Fd())) // so a script piping to us, or cron, is not blocked by a question.
+	}
+	return true
+}
+
+// input returns stdin for the console, or os.Stdin if it does not specify one.
+func (cfg *config) input() io.Reader {
+	if cfg.stdin != nil {
+		return cfg.stdin
+	}
+	return os.Stdin
+}
+
+// readLine reads a line from r, one byte at a time so that nothing after the line is consumed.  The line is returned
+// without its line ending.  An EOF after a partial line is not an error.
+func readLine(r io.Reader) (string, error) {
+	var buf []byte
+	var ch [1]byte
+	for {
+		n, err := r.Read(ch[:])
+		if n > 0 {
+			if ch[0] == '\n' {
+				break
+			}
+			buf = append(buf, ch[0])
+		}
+		if errors.Is(err, io.EOF) && len(buf) > 0 {
+			break
+		}
+		if err != nil {
+			return ``, err
+		}
+	}
+	return strings.TrimSuffix(string(buf), "\r"), nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+)
+
+func TestConfirm(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options []console.Option
+		want    bool
+	}{
+		{`yes`, []console.Option{consoletest.Stdin("y\n")}, true},
+		{`Yes`, []console.Option{consoletest.Stdin("Yes\r\n")}, true},
+		{`no`, []console.Option{consoletest.Stdin("n\n")}, false},
+		{`empty`, []console.Option{consoletest.Stdin("\n")}, false},
+		{`eof`, nil, false},
+		{`empty with default`, []console.Option{consoletest.Stdin("\n"), console.ConfirmDefault(true)}, true},
+		{`silent`, []console.Option{consoletest.Stdin("y\n"), console.Silent()}, false},
+		{`non-interactive`, []console.Option{consoletest.Stdin("y\n"), console.NonInteractive()}, false},
+		{`assume yes`, []console.Option{consoletest.Stdin("n\n"), console.AssumeYes()}, true},
+		{`pipe`, []console.Option{console.Stdin(pipe(t, "y\n"))}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, _, stderr := consoletest.New(tc.options...)
+			got, err := console.Confirm(ctx, `proceed?`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf(`got %v, want %v`, got, tc.want)
+			}
+			if tc.name == `pipe` && strings.Contains(stderr.String(), `proceed?`) {
+				t.Errorf(`asked a question of a pipe: %q`, stderr.String())
+			}
+		})
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	ctx, _, stderr := consoletest.New(consoletest.Stdin("alice\nbob\n"))
+	for _, want := range []string{`alice`, `bob`} {
+		got, err := console.Prompt(ctx, `name?`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf(`got %q, want %q`, got, want)
+		}
+	}
+	if got := stderr.String(); got != `name? name? ` {
+		t.Errorf(`got prompts %q`, got)
+	}
+
+	for _, option := range []console.Option{console.NonInteractive(), console.Silent(), console.Stdin(pipe(t, "alice\n"))} {
+		ctx, _, _ := consoletest.New(consoletest.Stdin("alice\n"), option)
+		if _, err := console.Prompt(ctx, `name?`); !errors.Is(err, console.ErrNotInteractive) {
+			t.Errorf(`got %v, want ErrNotInteractive`, err)
+		}
+	}
+}
+
+// pipe returns the read end of a pipe that will provide input, which is a file that is not a terminal.
+func pipe(t *testing.T, input string) *os.File {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestPromptSecret(t *testing.T) {
+	// wrapping the pipe hides that it is a file, like a reader that relays a pipe, so PromptSecret reads it plainly.
+	ctx, _, stderr := consoletest.New(console.Stdin(io.MultiReader(pipe(t, "hunter2\n"))))
+	got, err := console.PromptSecret(ctx, `password?`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `hunter2` {
+		t.Errorf(`got %q, want "hunter2"`, got)
+	}
+	if got := stderr.String(); got != `password? ` {
+		t.Errorf(`got prompt %q`, got)
+	}
+
+	ctx, _, _ = consoletest.New(console.Stdin(pipe(t, "hunter2\n")))
+	if _, err := console.PromptSecret(ctx, `password?`); !errors.Is(err, console.ErrNotInteractive) {
+		t.Errorf(`got %v from a pipe, want ErrNotInteractive`, err)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Shell identifies the syntax used by FormatCommandFor.
+type Shell int
+
+const (
+	// POSIX formats commands for sh and compatible shells, like AppendCommand.
+	POSIX Shell = iota
+
+	// PowerShell formats commands for Windows PowerShell and pwsh.
+	PowerShell
+)
+
+// FormatCommandFor formats cmd using the syntax of the specified shell, so it can be pasted into that shell.  Unknown
+// shells are treated as POSIX.
+func FormatCommandFor(shell Shell, cmd *exec.Cmd) string {
+	buf := make([]byte, 0, 256)
+	switch shell {
+	case PowerShell:
+		buf = appendPowerShellCommand(buf, cmd)
+	default:
+		buf = AppendCommand(buf, cmd)
+	}
+	return string(buf)
+}
+
+// appendPowerShellCommand appends cmd in PowerShell syntax, assigning novel environment variables with $env: first.
+func appendPowerShellCommand(buf []byte, cmd *exec.Cmd) []byte {
+	for _, env := range variableEnv(novelEnv(cmd.Env...)...) {
+		ofs := strings.IndexByte(env, '=')
+		buf = append(buf, "$env:"...)
+		buf = append(buf, env[:ofs]...)
+		buf = append(buf, " = "...)
+		buf = appendPowerShellLiteral(buf, env[ofs+1:])
+		buf = append(buf, "; "...)
+	}
+
+	path := shortCommandPath(cmd.Path)
+	if !rxPowerShellValue.MatchString(path) {
+		buf = append(buf, "& "...) // a quoted command is just a string unless it is invoked.
+	}
+	buf = appendPowerShellValue(buf, path)
+
+	if len(cmd.Args) > 1 {
+		for _, arg := range cmd.Args[1:] {
+			buf = append(buf, ' ')
+			buf = appendPowerShellValue(buf, arg)
+		}
+	}
+	return buf
+}
+
+func appendPowerShellValue(buf []byte, str string) []byte {
+	if rxPowerShellValue.MatchString(str) {
+		return append(buf, str...)
+	}
+	return appendPowerShellLiteral(buf, str)
+}
+
+// appendPowerShellLiteral appends str as a single quoted string, where the only escape is doubling a single quote.
+func appendPowerShellLiteral(buf []byte, str string) []byte {
+	buf = append(buf, '\'')
+	for _, ch := range []byte(str) {
+		if ch == '\'' {
+			buf = append(buf, '\'')
+		}
+		buf = append(buf, ch)
+	}
+	return append(buf, '\'')
+}
+
+var rxPowerShellValue = regexp.MustCompile(`^[A-Za-z0-9_./:\\=+-]+$`)
@@ -0,0 +1,47 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console_test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+)
+
+func TestFormatCommandFor(t *testing.T) {
+	for _, tc := range []struct {
+		cmd               *exec.Cmd
+		posix, powershell string
+	}{
+		{
+			&exec.Cmd{Path: `echo`, Args: []string{`echo`, `hello world`, `it's`}},
+			`echo 'hello world' 'it'\''s'`,
+			`echo 'hello world' 'it''s'`,
+		},
+		{
+			&exec.Cmd{Path: `C:\Program Files\tool.exe`, Args: []string{`tool`, `--flag=x`}},
+			`'C:\Program Files\tool.exe' --flag=x`,
+			`& 'C:\Program Files\tool.exe' --flag=x`,
+		},
+		{
+			&exec.Cmd{Path: `make`, Args: []string{`make`}, Env: []string{`ZUGZUG_TEST_MODE=a b`}},
+			`ZUGZUG_TEST_MODE='a b' make`,
+			`$env:ZUGZUG_TEST_MODE = 'a b'; make`,
+		},
+	} {
+		if got := console.FormatCommandFor(console.POSIX, tc.cmd); got != tc.posix {
+			t.Errorf(`got %s, want %s for POSIX`, got, tc.posix)
+		}
+		if got := console.FormatCommand(tc.cmd); got != tc.posix {
+			t.Errorf(`got %s, want %s by default`, got, tc.posix)
+		}
+		if got := console.FormatCommandFor(console.PowerShell, tc.cmd); got != tc.powershell {
+			t.Errorf(`got %s, want %s for PowerShell`, got, tc.powershell)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package throttle provides a writer that limits how many lines reach the underlying writer.  Like indent, this is
+// largely safe for concurrency but lines that span writes from different goroutines will share a count.
+package throttle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Interval is the minimum time between summaries of suppressed lines.
+var Interval = time.Second
+
+// Writer returns a writer that passes through the first n lines, then suppresses further lines, writing a summary
+// like "... (suppressed 42 lines)" at most once per Interval.  The writer has a Flush method, which console.Flush
+// calls, that writes a final summary and resets the count so that the next n lines are passed through.
+func Writer(w io.Writer, n int) io.Writer {
+	return &writer{io: w, limit: n}
+}
+
+type writer struct {
+	mu         sync.Mutex
+	io         io.Writer
+	limit      int
+	lines      int       // lines passed through
+	suppressed int       // lines suppressed
+	reported   int       // suppressed lines already reported
+	partial    bool      // true if suppressed output does not end with a newline
+	lastReport time.Time // when suppressed lines were last reported
+}
+
+// Write implements io.Writer.
+func (wr *writer) Write(p []byte) (int, error) {
+	originalSz := len(p)
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	var buf []byte
+	for len(p) > 0 && wr.lines < wr.limit {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			buf, p = append(buf, p...), nil
+			break
+		}
+		buf, p = append(buf, p[:i+1]...), p[i+1:]
+		wr.lines++
+		if wr.lines == wr.limit {
+			wr.lastReport = time.Now()
+		}
+	}
+	if len(p) > 0 {
+		wr.suppressed += bytes.Count(p, []byte{'\n'})
+		wr.partial = p[len(p)-1] != '\n'
+		if time.Since(wr.lastReport) >= Interval {
+			buf = wr.appendReport(buf)
+		}
+	}
+
+	if len(buf) == 0 {
+		return originalSz, nil
+	}
+	_, err := wr.io.Write(buf)
+	if err == nil {
+		return originalSz, nil
+	}
+	return 0, err
+}
+
+// Flush writes a final summary of suppressed lines, if any, and resets the count, then flushes the underlying writer
+// if it has a Flush method.
+func (wr *writer) Flush() error {
+	if err := wr.flush(); err != nil {
+		return err
+	}
+	if f, ok := wr.io.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (wr *writer) flush() error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	if wr.partial {
+		wr.suppressed++
+	}
+	buf := wr.appendReport(nil)
+	wr.lines, wr.suppressed, wr.reported, wr.partial = 0, 0, 0, false
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := wr.io.Write(buf)
+	return err
+}
+
+// appendReport appends a summary of suppressed lines to buf if there are any that have not been reported.
+func (wr *writer) appendReport(buf []byte) []byte {
+	if wr.suppressed == wr.reported {
+		return buf
+	}
+	wr.reported = wr.suppressed
+	wr.lastReport = time.Now()
+	return append(buf, fmt.Sprintf("... (suppressed %d lines)\n", wr.suppressed)...)
+}
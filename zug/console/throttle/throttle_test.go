@@ -0,0 +1,89 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package throttle_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swdunlop/zugzug-go/zug/console/throttle"
+)
+
+func TestWriter(t *testing.T) {
+	defer func(interval time.Duration) { throttle.Interval = interval }(throttle.Interval)
+	throttle.Interval = time.Hour
+
+	var buf bytes.Buffer
+	w := throttle.Writer(&buf, 10)
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+	}
+	if strings.Contains(buf.String(), `suppressed`) {
+		t.Errorf(`reported suppressed lines before the interval: %q`, buf.String())
+	}
+	if err := w.(interface{ Flush() error }).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 11 || lines[0] != `line 0` || lines[9] != `line 9` || lines[10] != `... (suppressed 990 lines)` {
+		t.Errorf(`got %q, want ten lines and a summary`, lines)
+	}
+
+	buf.Reset()
+	fmt.Fprint(w, "after flush\n")
+	if buf.String() != "after flush\n" {
+		t.Errorf(`got %q, Flush should reset the count`, buf.String())
+	}
+}
+
+func TestWriterInterval(t *testing.T) {
+	defer func(interval time.Duration) { throttle.Interval = interval }(throttle.Interval)
+	throttle.Interval = 0
+
+	var buf bytes.Buffer
+	w := throttle.Writer(&buf, 1)
+	fmt.Fprint(w, "a\nb\nc\n")
+	fmt.Fprint(w, "d\n")
+	if err := w.(interface{ Flush() error }).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\n... (suppressed 2 lines)\n... (suppressed 3 lines)\n"; buf.String() != want {
+		t.Errorf(`got %q, want %q`, buf.String(), want)
+	}
+}
+
+func TestWriterConcurrency(t *testing.T) {
+	defer func(interval time.Duration) { throttle.Interval = interval }(throttle.Interval)
+	throttle.Interval = time.Hour
+
+	var buf bytes.Buffer
+	w := throttle.Writer(&buf, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				fmt.Fprint(w, "line\n")
+			}
+		}()
+	}
+	wg.Wait()
+	if err := w.(interface{ Flush() error }).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "line\n"); n != 100 {
+		t.Errorf(`passed %v lines, want 100`, n)
+	}
+	if !strings.HasSuffix(buf.String(), "... (suppressed 900 lines)\n") {
+		t.Errorf(`got %q, want 900 lines suppressed`, buf.String())
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package truncate provides a writer that truncates long lines.  Like indent, this is largely safe for concurrency
+// but lines that span writes from different goroutines will share a count.
+package truncate
+
+import (
+	"io"
+	"sync"
+)
+
+// Marker is written in place of the remainder of a truncated line.
+const Marker = `…`
+
+// Writer returns a writer that transforms UTF-8 writes by dropping everything after the first n runes of each line,
+// writing Marker in their place.
+func Writer(w io.Writer, n int) io.Writer {
+	return &writer{io: w, limit: n}
+}
+
+type writer struct {
+	sync.Mutex
+	io       io.Writer
+	limit    int
+	runes    int  // runes started on the current line
+	dropping bool // true once the current line has been truncated
+}
+
+func (wr *writer) Write(p []byte) (int, error) {
+	originalSz := len(p)
+	if originalSz == 0 {
+		return 0, nil
+	}
+	wr.Lock()
+	defer wr.Unlock()
+
+	buf := make([]byte, 0, len(p)+len(Marker))
+	for _, ch := range p {
+		switch {
+		case ch == '\n':
+			wr.runes, wr.dropping = 0, false
+		case ch&0xC0 == 0x80:
+			// continuation bytes belong to the rune before them.
+			if wr.dropping {
+				continue
+			}
+		case wr.dropping:
+			continue
+		case wr.runes >= wr.limit:
+			wr.dropping = true
+			buf = append(buf, Marker...)
+			continue
+		default:
+			wr.runes++
+		}
+		buf = append(buf, ch)
+	}
+
+	_, err := wr.io.Write(buf)
+	if err == nil {
+		return originalSz, nil
+	}
+	return 0, err
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package truncate_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console/truncate"
+)
+
+func TestWriter(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{`short`, []string{"abc\n"}, "abc\n"},
+		{`exact`, []string{"abcde\n"}, "abcde\n"},
+		{`long`, []string{"abcdefgh\nij\n"}, "abcde" + truncate.Marker + "\nij\n"},
+		{`split`, []string{"abc", "defgh", "\nij\n"}, "abcde" + truncate.Marker + "\nij\n"},
+		{`runes`, []string{"héllö wörld\n"}, "héllö" + truncate.Marker + "\n"},
+		{`split rune`, []string{"abcd\xc3", "\xa9fg\n"}, "abcdé" + truncate.Marker + "\n"},
+		{`dropped rune`, []string{"abcde\xc3", "\xa9fg\n"}, "abcde" + truncate.Marker + "\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := truncate.Writer(&buf, 5)
+			for _, p := range tc.writes {
+				if n, err := w.Write([]byte(p)); err != nil || n != len(p) {
+					t.Fatalf(`wrote %v of %v bytes: %v`, n, len(p), err)
+				}
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf(`got %q, want %q`, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zug
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger derives a new context where Logger will use the provided logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxLogger{}, logger)
+}
+
+// Logger returns the logger from WithLogger, or slog.Default, with a "task" attribute naming the current task if
+// there is one.
+func Logger(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(ctxLogger{}).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+	if task := CurrentTask(ctx); task != `` {
+		logger = logger.With(`task`, task)
+	}
+	return logger
+}
+
+type ctxLogger struct{}
@@ -0,0 +1,44 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zug_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := zug.WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)))
+	zug.Logger(ctx).Info(`outside`)
+	err := zug.Run(ctx, zug.Alias(`build`, zug.New(func(ctx context.Context) error {
+		zug.Logger(ctx).Info(`inside`)
+		return nil
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	for _, want := range []struct{ msg, task string }{{`outside`, ``}, {`inside`, `build`}} {
+		var record struct {
+			Msg  string `json:"msg"`
+			Task string `json:"task"`
+		}
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Msg != want.msg || record.Task != want.task {
+			t.Errorf(`got %q with task %q, want %q with task %q`, record.Msg, record.Task, want.msg, want.task)
+		}
+	}
+}
@@ -39,6 +39,14 @@ func New(options ...Option) BoolFlagger {
 	return &cfg
 }
 
+// Leading constructs a new parser using pflag flags that stops at the first argument that is not a flag, leaving it
+// and everything after it for Args.  This is how zugzug.Globals parses flags that precede the command.
+func Leading(options ...Option) BoolFlagger {
+	cfg := config{leading: true}
+	cfg.options = append(cfg.options, options...)
+	return &cfg
+}
+
 // Apply applies a series of options as an option.
 func Apply(options ...Option) Option {
 	return func(fs *pflag.FlagSet) {
@@ -102,11 +110,29 @@ func Var(p Value, name, shorthand string, usage string) Option {
 	return func(fs *pflag.FlagSet) { fs.VarP(p, name, shorthand, usage) }
 }
 
+// Aliases applies FlagSet.SetNormalizeFunc as an Option so that each alias is accepted in place of the canonical flag
+// name.  Help will only mention the canonical name.
+func Aliases(canonical string, aliases ...string) Option {
+	return func(fs *pflag.FlagSet) {
+		next := fs.GetNormalizeFunc()
+		fs.SetNormalizeFunc(func(fs *pflag.FlagSet, name string) pflag.NormalizedName {
+			for _, alias := range aliases {
+				if name == alias {
+					name = canonical
+					break
+				}
+			}
+			return next(fs, name)
+		})
+	}
+}
+
 // An Option configures the provided flag set in advance of Usage or Parse.
 type Option func(*pflag.FlagSet)
 
 type config struct {
 	options []Option
+	leading bool // if true, stop parsing at the first non-flag argument.
 }
 
 // Parse implements Parser.
@@ -139,6 +165,31 @@ func (cfg *config) Help(name string) string {
 	return buf.String()
 }
 
+// Flags implements zugzug.FlagLister by describing the flags configured by the parser.
+func (cfg *config) Flags() []Flag {
+	fs := cfg.flagset(``)
+	flags := make([]Flag, 0, 8)
+	fs.VisitAll(func(f *pflag.Flag) {
+		flags = append(flags, Flag{
+			Name:      f.Name,
+			Shorthand: f.Shorthand,
+			Usage:     f.Usage,
+			Default:   f.DefValue,
+			Type:      f.Value.Type(),
+		})
+	})
+	return flags
+}
+
+// Flag describes a flag configured by a parser.
+type Flag struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	Usage     string `json:"use,omitempty"`
+	Default   string `json:"default,omitempty"`
+	Type      string `json:"type"`
+}
+
 // BoolFlag implements zugzug.BoolFlagger, allowing zugzug to add global boolean flags like -q / --quiet
 func (cfg *config) BoolFlag(p *bool, name, shorthand string, usage string) {
 	cfg.options = append(cfg.options, func(fs *pflag.FlagSet) {
@@ -146,12 +197,18 @@ func (cfg *config) BoolFlag(p *bool, name, shorthand string, usage string) {
 	})
 }
 
+// Flag implements zugzug.Flagger, allowing zugzug to add flags of any type, like --timeout.
+func (cfg *config) Flag(option Option) {
+	cfg.options = append(cfg.options, option)
+}
+
 type ctxArgs struct{}
 
 // flagset composes a new flagset with the provided name and applies options.
 func (cfg *config) flagset(name string) *pflag.FlagSet {
 	fs := pflag.NewFlagSet(name, pflag.ContinueOnError)
 	fs.Usage = func() {} // do nothing, we will return nil, nil instead.
+	fs.SetInterspersed(!cfg.leading)
 	for _, opt := range cfg.options {
 		opt(fs)
 	}
@@ -226,3 +283,10 @@ type BoolFlagger interface {
 	Interface
 	BoolFlag(p *bool, name, shorthand, usage string)
 }
+
+// Flagger is an optional interface that is implemented by parser.New that lets zugzug add flags of any type before it
+// parses.
+type Flagger interface {
+	Interface
+	Flag(option Option)
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package parser_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/parser"
+)
+
+func TestAliases(t *testing.T) {
+	var output string
+	p := parser.New(
+		parser.String(&output, `output`, `o`, `file to write`),
+		parser.Aliases(`output`, `out`, `dest`),
+	)
+	for _, args := range [][]string{{`--output`, `x`}, {`--out`, `x`}, {`--dest=x`}, {`-o`, `x`}} {
+		output = ``
+		if _, err := p.Parse(context.Background(), `write`, args); err != nil {
+			t.Fatalf(`%q: %v`, args, err)
+		}
+		if output != `x` {
+			t.Errorf(`%q set output to %q, want "x"`, args, output)
+		}
+	}
+	help := p.(interface{ Help(string) string }).Help(`write`)
+	if !strings.Contains(help, `--output`) || strings.Contains(help, `--out `) || strings.Contains(help, `--dest`) {
+		t.Errorf("help should only list the canonical flag:\n%s", help)
+	}
+}
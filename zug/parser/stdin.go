@@ -0,0 +1,90 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+)
+
+// FileOrStdin returns a Parser that accepts an optional path as its only argument.  If the path is provided, a reader
+// for the file, relative to the console directory, is stored in p, otherwise the console's stdin (or os.Stdin) is
+// stored in p.  A path of "-" also means stdin.  The task is responsible for closing p, but closing stdin does nothing.
+//
+// The file is checked when the arguments are parsed, but not opened until it is first read, so nothing leaks if the
+// task never runs because a later command line argument or validation fails.
+func FileOrStdin(p *io.ReadCloser, usage string) Interface {
+	return fileOrStdin{p, usage}
+}
+
+type fileOrStdin struct {
+	p     *io.ReadCloser
+	usage string
+}
+
+// Parse implements Parser.
+func (cfg fileOrStdin) Parse(ctx context.Context, name string, arguments []string) (context.Context, error) {
+	switch len(arguments) {
+	case 0:
+		*cfg.p = io.NopCloser(console.From(ctx).Stdin())
+	case 1:
+		switch path := arguments[0]; path {
+		case `-h`, `--help`:
+			return nil, nil
+		case `-`:
+			*cfg.p = io.NopCloser(console.From(ctx).Stdin())
+		default:
+			if dir := console.From(ctx).Dir(); dir != `` && !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			if _, err := os.Stat(path); err != nil {
+				return nil, err
+			}
+			*cfg.p = &lazyFile{path: path}
+		}
+	default:
+		return nil, fmt.Errorf(`%v expects at most one file, got %v arguments`, name, len(arguments))
+	}
+	return context.WithValue(ctx, ctxArgs{}, arguments), nil
+}
+
+// Help implements zugzug.Helper by explaining the optional file.
+func (cfg fileOrStdin) Help(name string) string {
+	return fmt.Sprintf("COMMAND: %s [file]\n  %s\n  If file is omitted or \"-\", reads from stdin.\n", name, cfg.usage)
+}
+
+// lazyFile is an io.ReadCloser that opens path when it is first read.
+type lazyFile struct {
+	path string
+	f    *os.File
+	err  error
+}
+
+// Read implements io.Reader, opening the file first if needed.
+func (lf *lazyFile) Read(p []byte) (int, error) {
+	if lf.f == nil && lf.err == nil {
+		lf.f, lf.err = os.Open(lf.path)
+	}
+	if lf.err != nil {
+		return 0, lf.err
+	}
+	return lf.f.Read(p)
+}
+
+// Close implements io.Closer, closing the file if it was opened.
+func (lf *lazyFile) Close() error {
+	if lf.f == nil {
+		lf.err = os.ErrClosed
+		return nil
+	}
+	return lf.f.Close()
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package parser_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+	"github.com/swdunlop/zugzug-go/zug/parser"
+)
+
+func TestFileOrStdin(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, `input.txt`), []byte(`from file`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{`file`, []string{`input.txt`}, `from file`},
+		{`dash`, []string{`-`}, `from stdin`},
+		{`none`, nil, `from stdin`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var r io.ReadCloser
+			ctx, _, _ := consoletest.New(consoletest.Stdin(`from stdin`), console.Dir(dir))
+			if _, err := parser.FileOrStdin(&r, `reads input`).Parse(ctx, `filter`, tc.args); err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tc.want {
+				t.Errorf(`read %q, want %q`, data, tc.want)
+			}
+			if err := r.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	var r io.ReadCloser
+	ctx, _, _ := consoletest.New(console.Dir(dir))
+	if _, err := parser.FileOrStdin(&r, `reads input`).Parse(ctx, `filter`, []string{`missing.txt`}); !os.IsNotExist(err) {
+		t.Errorf(`got %v for a missing file, want it not to exist`, err)
+	}
+	if _, err := parser.FileOrStdin(&r, `reads input`).Parse(ctx, `filter`, []string{`a`, `b`}); err == nil {
+		t.Error(`expected an error for two files`)
+	}
+	if _, err := parser.FileOrStdin(&r, `reads input`).Parse(ctx, `filter`, []string{`input.txt`}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf(`closing a file that was never read: %v`, err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"context"
 	"reflect"
 	"sync"
+	"sync/atomic"
 )
 
 // Run will run the provided task in its context, returning the error if any.
@@ -26,7 +27,7 @@ func With(ctx context.Context, options ...Option) context.Context {
 	for _, option := range options {
 		w = option(w)
 	}
-	return context.WithValue(ctx, ctxWorker{}, &w)
+	return context.WithValue(ctx, ctxWorker{}, w)
 }
 
 // EmptyState provides an option with no state tracking.  Any task started in the previous context can be run again
@@ -77,16 +78,44 @@ type worker struct {
 
 func (w *worker) run(ctx context.Context, id uint, fn func(context.Context) error) error {
 	pc := reflect.ValueOf(fn).Pointer()
-	rid := resultID{id, pc}
+	state := w.result(resultID{id, pc})
+	state.once.Do(func() { state.err = fn(ctx) })
+	return state.err
+}
+
+func (w *worker) result(rid resultID) *result {
 	w.control.Lock()
+	defer w.control.Unlock()
 	state, ok := w.state[rid]
 	if !ok {
 		state = &result{}
 		w.state[rid] = state
 	}
+	return state
+}
+
+// RunValue is like Run, but fn also produces a value, which is kept with the state of the task so it can be retrieved
+// using Value.  Since fn is often a closure, the task is identified by id and key, usually the entry point of the
+// function fn wraps.
+func RunValue(ctx context.Context, id uint, key uintptr, fn func(context.Context) (any, error)) (any, error) {
+	state := from(ctx).result(resultID{id, key})
+	state.once.Do(func() {
+		state.value, state.err = fn(ctx)
+		state.done.Store(true)
+	})
+	return state.value, state.err
+}
+
+// Value returns the value produced by the task identified by id and key in RunValue, if it has finished.
+func Value(ctx context.Context, id uint, key uintptr) (any, bool) {
+	w := from(ctx)
+	w.control.Lock()
+	state, ok := w.state[resultID{id, key}]
 	w.control.Unlock()
-	state.once.Do(func() { state.err = fn(ctx) })
-	return state.err
+	if !ok || !state.done.Load() {
+		return nil, false
+	}
+	return state.value, true
 }
 
 type resultID struct {
@@ -95,8 +124,10 @@ type resultID struct {
 }
 
 type result struct {
-	once sync.Once
-	err  error
+	once  sync.Once
+	value any
+	err   error
+	done  atomic.Bool // set once RunValue has a value
 }
 
 type ctxWorker struct{}
@@ -8,6 +8,7 @@ package zug
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"runtime"
@@ -17,22 +18,42 @@ import (
 	"github.com/swdunlop/zugzug-go/zug/worker"
 )
 
-// WithLocalState will provide a new context with local state table for tracking whether a task has been run.  This
-// is useful for enabling running tasks multiple times.
+// WithLocalState will provide a new context with a local state table for tracking whether a task has been run, using
+// worker.LocalState.  Tasks that already ran keep their results, but tasks first run in the new context may run again
+// in the original context or another local context.  Note that this only affects functions given to Run and Start;
+// tasks made by New always run at most once.
 func WithLocalState(ctx context.Context) context.Context {
-	return context.WithValue(ctx, ctxState{}, make(map[any]bool))
+	return worker.With(ctx, worker.LocalState())
 }
 
-type ctxState struct{}
+// WithCancel returns a copy of ctx that will be canceled when Cancel is called with it, or any context derived from
+// it, along with a function that also cancels it.
+func WithCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	return context.WithValue(ctx, ctxCancel{}, cancel), cancel
+}
+
+// Cancel cancels the context provided by the nearest WithCancel, which lets a task stop the rest of a run.  It does
+// nothing if ctx was not derived from WithCancel.
+func Cancel(ctx context.Context) {
+	if cancel, ok := ctx.Value(ctxCancel{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+type ctxCancel struct{}
 
 // Run will run each of the tasks in the order they are specified, sequentially, waiting until they complete and
-// and stopping at the first failure.
+// and stopping at the first failure or when ctx is canceled.
 func Run(ctx context.Context, tasks ...any) error {
 	actualTasks, err := toTasks(tasks)
 	if err != nil {
 		return err
 	}
 	for _, task := range actualTasks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		err := runTask(ctx, task)
 		if err.Err != nil {
 			return err
@@ -44,6 +65,12 @@ func Run(ctx context.Context, tasks ...any) error {
 // Start is similar to Run, but will run each task in parallel, waiting until they complete and returning Errors if
 // any failed.
 func Start(ctx context.Context, tasks ...any) error {
+	return StartWith(ctx, nil, tasks...)
+}
+
+// StartWith is similar to Start, but derives the context for each task using with, which receives the name of the
+// task.  This is how console.StartLabeled prefixes the output of each task with its name.
+func StartWith(ctx context.Context, with func(ctx context.Context, task string) context.Context, tasks ...any) error {
 	actualTasks, err := toTasks(tasks)
 	if err != nil {
 		return err
@@ -52,9 +79,13 @@ func Start(ctx context.Context, tasks ...any) error {
 	var wg sync.WaitGroup
 	wg.Add(len(actualTasks))
 	for i, t := range actualTasks {
+		taskCtx := ctx
+		if with != nil {
+			taskCtx = with(ctx, taskName(t))
+		}
 		go func(i int, t Task) {
 			defer wg.Done()
-			taskErrors[i] = runTask(ctx, t)
+			taskErrors[i] = runTask(taskCtx, t)
 		}(i, t)
 	}
 	wg.Wait()
@@ -71,6 +102,36 @@ func Start(ctx context.Context, tasks ...any) error {
 	return taskErrors[:n]
 }
 
+// StartFailFast is similar to Start, but cancels the context provided to the other tasks as soon as any task fails.
+// It waits for the other tasks to finish, then returns the Error from the first failure.
+func StartFailFast(ctx context.Context, tasks ...any) error {
+	actualTasks, err := toTasks(tasks)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failure  error
+	)
+	wg.Add(len(actualTasks))
+	for _, t := range actualTasks {
+		go func(t Task) {
+			defer wg.Done()
+			if err := runTask(ctx, t); err.Err != nil {
+				failOnce.Do(func() {
+					failure = err
+					cancel()
+				})
+			}
+		}(t)
+	}
+	wg.Wait()
+	return failure
+}
+
 // Alias will rename a task, which is useful if you have multiple tasks created by New but want to give them different
 // names.
 func Alias(name string, task Task) NamedTask { return aliasTask{name: name, task: task} }
@@ -94,10 +155,37 @@ type wrapTask struct {
 }
 
 func (t *wrapTask) RunTask(ctx context.Context) error {
+	if ctx.Value(ctxRehearsal{}) != nil {
+		_, err := worker.RunValue(ctx, 0, reflect.ValueOf(t).Pointer(), func(ctx context.Context) (any, error) {
+			return nil, t.fn(ctx)
+		})
+		return err
+	}
 	t.once.Do(func() { t.err = t.fn(ctx) })
 	return t.err
 }
 
+// Rehearse derives a context where tasks made by New track whether they ran using the worker state of ctx instead of
+// their own sync.Once, so running them in a rehearsal, like planning the commands they would run, does not keep them
+// from running again later.  This is usually combined with worker.EmptyState.
+func Rehearse(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxRehearsal{}, true)
+}
+
+type ctxRehearsal struct{}
+
+// Reset lets a task created by New run again, even if it is wrapped by Alias.  Other tasks are unaffected.  This is not
+// safe to call while the task may be running.
+func Reset(task Task) {
+	switch t := task.(type) {
+	case aliasTask:
+		Reset(t.task)
+	case *wrapTask:
+		t.once = sync.Once{}
+		t.err = nil
+	}
+}
+
 func (t *wrapTask) TaskName() string {
 	return fnTaskName(t.fn)
 }
@@ -132,11 +220,54 @@ func toTask(t any) (Task, error) {
 		return fnTask{pc, func(context.Context) error { return t() }}, nil
 	case func():
 		return fnTask{pc, func(context.Context) error { t(); return nil }}, nil
-	default:
-		return nil, fmt.Errorf(`cannot convert function %v to a task`, fnTaskName(t))
 	}
+	if isValueFunc(rv.Type()) {
+		return valueTask{pc, func(ctx context.Context) (any, error) {
+			out := rv.Call([]reflect.Value{reflect.ValueOf(ctx)})
+			err, _ := out[1].Interface().(error)
+			return out[0].Interface(), err
+		}}, nil
+	}
+	return nil, fmt.Errorf(`cannot convert function %v to a task`, fnTaskName(t))
 }
 
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// isValueFunc returns true if ft is like func(context.Context) (T, error).
+func isValueFunc(ft reflect.Type) bool {
+	return ft.NumIn() == 1 && ft.In(0) == contextType && ft.NumOut() == 2 && ft.Out(1) == errorType
+}
+
+// Result runs task, like Run, and returns the value it produced.  Any function like func(context.Context) (T, error)
+// may be given to Run or Start as a task; Result lets a dependent task retrieve its value.
+func Result[T any](ctx context.Context, task func(context.Context) (T, error)) (T, error) {
+	var value T
+	err := Run(ctx, task)
+	if err != nil {
+		return value, err
+	}
+	if v, ok := worker.Value(ctx, 0, reflect.ValueOf(task).Pointer()); ok {
+		value, _ = v.(T)
+	}
+	return value, nil
+}
+
+// valueTask is a task for a function that produces a value, which is kept by the worker for Result.
+type valueTask struct {
+	pc uintptr
+	fn func(context.Context) (any, error)
+}
+
+func (t valueTask) RunTask(ctx context.Context) error {
+	_, err := worker.RunValue(ctx, 0, t.pc, t.fn)
+	return err
+}
+
+func (t valueTask) TaskName() string { return pcTaskName(t.pc) }
+
 type fnTask struct {
 	pc uintptr
 	fn func(context.Context) error
@@ -181,16 +312,42 @@ func (errs Errors) condense() error {
 }
 
 // Error implements the error interface by returning an empty string if there are no errors, the first
-// error if there was only one, or a string indicating the number of errors if there were more.
+// error if there was only one, or a line indicating the number of errors followed by a line for each error if there
+// were more.
 func (errs Errors) Error() string {
 	switch len(errs) {
 	case 0:
 		return ``
 	case 1:
 		return errs[0].Error()
-	default:
-		return fmt.Sprintf(`%v errors`, len(errs))
 	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, `%v errors:`, len(errs))
+	for _, err := range errs {
+		buf.WriteString("\n  ")
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+// Is lets errors.Is find target among the errors.
+func (errs Errors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As lets errors.As find target among the errors, such as the Error for a specific task.
+func (errs Errors) As(target any) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
 }
 
 func runTask(ctx context.Context, task Task) (e Error) {
@@ -212,14 +369,43 @@ func runTask(ctx context.Context, task Task) (e Error) {
 			panic(r)
 		}
 	}()
-	e.Err = task.RunTask(ctx)
+	e.Err = task.RunTask(context.WithValue(ctx, ctxTask{}, e.Task))
+	if errors.Is(e.Err, ErrSkip) {
+		if note, ok := ctx.Value(ctxSkipNote{}).(func(context.Context, string, error)); ok {
+			note(ctx, e.Task, e.Err)
+		}
+		e.Err = nil
+	}
 	return
 }
 
+// WithSkipNote derives a context where Run and Start pass the name of each task that returns ErrSkip, and the error it
+// returned, to note before treating the skip as success.  This includes tasks run by other tasks.
+func WithSkipNote(ctx context.Context, note func(ctx context.Context, task string, err error)) context.Context {
+	return context.WithValue(ctx, ctxSkipNote{}, note)
+}
+
+type ctxSkipNote struct{}
+
+// ErrSkip may be returned, or wrapped, by a task to indicate it had nothing to do.  Run and Start treat this as success.
+var ErrSkip = errors.New(`skipped`)
+
+// CurrentTask returns the name of the innermost task being run with ctx, or an empty string if there is none.
+func CurrentTask(ctx context.Context) string {
+	name, _ := ctx.Value(ctxTask{}).(string)
+	return name
+}
+
+type ctxTask struct{}
+
 // Debug will disable recovery from panics and will instead allow them to propagate.  This is not ideal if you are running tasks
 // in parallel, but it is useful for debugging.
 var Debug = false
 
+// RecoverPanics controls whether a panicking task is reported as an Error for that task with its stack, which is the
+// default, or allowed to crash the program.  This is the same as setting Debug to !enabled.
+func RecoverPanics(enabled bool) { Debug = !enabled }
+
 // An Error is an error that knows which task it came from.
 type Error struct {
 	Task  string
@@ -227,11 +413,39 @@ type Error struct {
 	Stack []uintptr // Only set if a panic occurred during the task.
 }
 
-// Error implements the error interface by returning a string indicating the "task: error".
+// Error implements the error interface by returning a string indicating the "task: error".  If ShowStacks is true
+// and the task panicked, this is followed by the stack.
 func (err Error) Error() string {
+	if ShowStacks && len(err.Stack) > 0 {
+		return fmt.Sprintf("%v: %v\n%v", err.Task, err.Err, err.StackTrace())
+	}
 	return fmt.Sprintf(`%v: %v`, err.Task, err.Err)
 }
 
+// StackTrace formats Stack with a line for each function followed by an indented line with its file and line, or
+// returns an empty string if the task did not panic.
+func (err Error) StackTrace() string {
+	if len(err.Stack) == 0 {
+		return ``
+	}
+	var buf strings.Builder
+	frames := runtime.CallersFrames(err.Stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&buf, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return buf.String()
+}
+
+// ShowStacks will include the stack of a recovered panic in the message for its Error.
+var ShowStacks = false
+
+// Unwrap returns the underlying error so errors.Is and errors.As can inspect it.
+func (err Error) Unwrap() error { return err.Err }
+
 func taskName(t Task) string {
 	if nt, ok := t.(NamedTask); ok {
 		return nt.TaskName()
@@ -0,0 +1,286 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zug_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swdunlop/zugzug-go/zug"
+	"github.com/swdunlop/zugzug-go/zug/worker"
+)
+
+func TestSkip(t *testing.T) {
+	var mu sync.Mutex // Start runs both tasks at once.
+	var ran []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, name)
+	}
+	skip := func(ctx context.Context) error {
+		record(`skip`)
+		return fmt.Errorf(`%w: nothing changed`, zug.ErrSkip)
+	}
+	next := func(ctx context.Context) error {
+		record(`next`)
+		return nil
+	}
+	ctx := worker.With(context.Background(), worker.EmptyState()) // so earlier runs of this test do not count.
+	if err := zug.Run(ctx, skip, next); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 2 {
+		t.Errorf(`ran %q, want the task after the skip to run`, ran)
+	}
+
+	ran = nil
+	if err := zug.Start(worker.With(context.Background(), worker.EmptyState()), skip, next); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 2 {
+		t.Errorf(`started %q, want both tasks`, ran)
+	}
+
+	var noted []string
+	ctx = zug.WithSkipNote(worker.With(context.Background(), worker.EmptyState()),
+		func(ctx context.Context, task string, err error) { noted = append(noted, task) })
+	inner := zug.Alias(`inner`, zug.New(skip))
+	outer := zug.Alias(`outer`, zug.New(func(ctx context.Context) error { return zug.Run(ctx, inner) }))
+	if err := zug.Run(ctx, outer); err != nil {
+		t.Fatal(err)
+	}
+	if len(noted) != 1 || noted[0] != `inner` {
+		t.Errorf(`noted %q, want the skip of the inner task`, noted)
+	}
+}
+
+func TestWithLocalState(t *testing.T) {
+	runs := make(map[string]int)
+	a := func(ctx context.Context) error { runs[`a`]++; return nil }
+	b := func(ctx context.Context) error { runs[`b`]++; return nil }
+	run := func(ctx context.Context, task any) {
+		t.Helper()
+		if err := zug.Run(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := worker.With(context.Background(), worker.EmptyState())
+	run(ctx, a)
+	local, other := zug.WithLocalState(ctx), zug.WithLocalState(ctx)
+	run(local, a) // already ran in the original context, so it keeps its result.
+	run(local, b)
+	run(local, b) // already ran in this local context.
+	run(other, b) // first ran in a different local context, so it runs again.
+	run(ctx, b)   // likewise for the original context.
+
+	if want := map[string]int{`a`: 1, `b`: 3}; !reflect.DeepEqual(runs, want) {
+		t.Errorf(`got runs %v, want %v`, runs, want)
+	}
+}
+
+func TestReset(t *testing.T) {
+	runs := 0
+	task := zug.New(func(ctx context.Context) error {
+		runs++
+		return fmt.Errorf(`run %v`, runs)
+	})
+	alias := zug.Alias(`task`, task)
+	ctx := context.Background()
+	for _, want := range []string{`run 1`, `run 1`} {
+		if err := zug.Run(ctx, task); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf(`got %v, want %v`, err, want)
+		}
+	}
+	zug.Reset(alias) // resets the task wrapped by the alias.
+	if err := zug.Run(ctx, task); err == nil || !strings.Contains(err.Error(), `run 2`) {
+		t.Errorf(`got %v after Reset, want run 2`, err)
+	}
+	if runs != 2 {
+		t.Errorf(`ran %v times, want 2`, runs)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	var ran []string
+	stop := func(ctx context.Context) error {
+		ran = append(ran, `stop`)
+		zug.Cancel(ctx)
+		return nil
+	}
+	next := func(ctx context.Context) error {
+		ran = append(ran, `next`)
+		return nil
+	}
+	ctx, cancel := zug.WithCancel(worker.With(context.Background(), worker.EmptyState()))
+	defer cancel()
+	err := zug.Run(ctx, stop, next)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf(`got %v, want context.Canceled`, err)
+	}
+	if want := []string{`stop`}; !reflect.DeepEqual(ran, want) {
+		t.Errorf(`ran %q, want %q`, ran, want)
+	}
+	zug.Cancel(context.Background()) // does nothing without WithCancel.
+}
+
+func TestErrors(t *testing.T) {
+	errSentinel := errors.New(`sentinel`)
+	ctx := worker.With(context.Background(), worker.EmptyState())
+	err := zug.Start(ctx,
+		zug.Alias(`ok`, zug.New(func(ctx context.Context) error { return nil })),
+		zug.Alias(`plain`, zug.New(func(ctx context.Context) error { return errors.New(`plain failure`) })),
+		zug.Alias(`special`, zug.New(func(ctx context.Context) error { return fmt.Errorf(`%w failure`, errSentinel) })),
+	)
+	var errs zug.Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf(`got %#v, want Errors for two tasks`, err)
+	}
+	if !errors.Is(err, errSentinel) {
+		t.Error(`errors.Is did not find the sentinel among the errors`)
+	}
+	var taskErr zug.Error
+	if !errors.As(err, &taskErr) || taskErr.Task != `plain` {
+		t.Errorf(`errors.As found %+v, want the Error for plain`, taskErr)
+	}
+	for _, want := range []string{"2 errors:", "\n  plain: plain failure", "\n  special: sentinel failure"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf(`%q does not contain %q`, err.Error(), want)
+		}
+	}
+
+	one := zug.Errors{{Task: `plain`, Err: errors.New(`plain failure`)}}
+	if got := one.Error(); got != `plain: plain failure` {
+		t.Errorf(`got %q for one error`, got)
+	}
+	if got := (zug.Errors{}).Error(); got != `` {
+		t.Errorf(`got %q for no errors`, got)
+	}
+}
+
+type panicTask struct{}
+
+func (panicTask) TaskName() string { return `explode` }
+
+func (panicTask) RunTask(ctx context.Context) error { panic(`boom`) }
+
+func TestRecoverPanics(t *testing.T) {
+	defer zug.RecoverPanics(true)
+	ctx := worker.With(context.Background(), worker.EmptyState())
+
+	zug.RecoverPanics(true)
+	err := zug.Run(ctx, panicTask{})
+	var taskErr zug.Error
+	if !errors.As(err, &taskErr) {
+		t.Fatalf(`got %#v, want an Error`, err)
+	}
+	if taskErr.Task != `explode` || taskErr.Err == nil || taskErr.Err.Error() != `boom` {
+		t.Errorf(`got %+v, want boom from explode`, taskErr)
+	}
+	if len(taskErr.Stack) == 0 {
+		t.Error(`a recovered panic should capture the stack`)
+	}
+
+	zug.RecoverPanics(false)
+	defer func() {
+		if r := recover(); r != `boom` {
+			t.Errorf(`recovered %v, want the panic to propagate`, r)
+		}
+	}()
+	_ = zug.Run(ctx, panicTask{})
+	t.Error(`Run returned instead of panicking`)
+}
+
+func explode(ctx context.Context) error { panic(`boom`) }
+
+func TestStackTrace(t *testing.T) {
+	defer func() { zug.ShowStacks = false }()
+	ctx := worker.With(context.Background(), worker.EmptyState())
+	var err zug.Error
+	if !errors.As(zug.Run(ctx, explode), &err) {
+		t.Fatal(`the panic was not reported as an Error`)
+	}
+	stack := err.StackTrace()
+	if !strings.Contains(stack, `zug_test.explode`) {
+		t.Errorf("stack does not mention explode:\n%s", stack)
+	}
+	if strings.Contains(err.Error(), stack) {
+		t.Errorf(`%q includes the stack without ShowStacks`, err.Error())
+	}
+	zug.ShowStacks = true
+	if !strings.HasSuffix(err.Error(), stack) {
+		t.Errorf(`%q does not include the stack with ShowStacks`, err.Error())
+	}
+	if (zug.Error{Task: `calm`, Err: errors.New(`oops`)}).StackTrace() != `` {
+		t.Error(`an error without a panic has a stack`)
+	}
+}
+
+func TestStartFailFast(t *testing.T) {
+	ctx := worker.With(context.Background(), worker.EmptyState())
+	var sleeperErr error
+	fail := zug.Alias(`fail`, zug.New(func(ctx context.Context) error { return errors.New(`failed fast`) }))
+	sleep := zug.Alias(`sleep`, zug.New(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			sleeperErr = ctx.Err()
+		case <-time.After(10 * time.Second):
+		}
+		return sleeperErr
+	}))
+	started := time.Now()
+	err := zug.StartFailFast(ctx, sleep, fail)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf(`took %v, the sleeper was not cancelled`, elapsed)
+	}
+	var taskErr zug.Error
+	if !errors.As(err, &taskErr) || taskErr.Task != `fail` {
+		t.Errorf(`got %v, want the error from fail`, err)
+	}
+	if !errors.Is(sleeperErr, context.Canceled) {
+		t.Errorf(`sleeper saw %v, want context.Canceled`, sleeperErr)
+	}
+}
+
+var versionRuns int
+
+func version(ctx context.Context) (string, error) {
+	versionRuns++
+	return `v1.2.3`, nil
+}
+
+func brokenVersion(ctx context.Context) (string, error) { return ``, errors.New(`no tags`) }
+
+func TestResult(t *testing.T) {
+	versionRuns = 0
+	ctx := worker.With(context.Background(), worker.EmptyState())
+	var built string
+	build := func(ctx context.Context) error {
+		v, err := zug.Result(ctx, version)
+		built = `app-` + v
+		return err
+	}
+	if err := zug.Run(ctx, version, build); err != nil {
+		t.Fatal(err)
+	}
+	if built != `app-v1.2.3` || versionRuns != 1 {
+		t.Errorf(`built %q with %v runs of version, want app-v1.2.3 with 1`, built, versionRuns)
+	}
+	if v, err := zug.Result(ctx, version); err != nil || v != `v1.2.3` || versionRuns != 1 {
+		t.Errorf(`got %q, %v after %v runs`, v, err, versionRuns)
+	}
+	if _, err := zug.Result(ctx, brokenVersion); err == nil || !strings.Contains(err.Error(), `no tags`) {
+		t.Errorf(`got %v, want the error from brokenVersion`, err)
+	}
+}
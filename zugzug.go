@@ -9,65 +9,235 @@ package zugzug
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/swdunlop/zugzug-go/zug"
 	"github.com/swdunlop/zugzug-go/zug/console"
 	"github.com/swdunlop/zugzug-go/zug/parser"
+	"github.com/swdunlop/zugzug-go/zug/worker"
 )
 
 // Main will assemble a configuration of tasks that can be performed with the provided options, and then run them based
 // on the command line arguments.
 func Main(options ...Option) {
-	err := runMain(options...)
+	cfg := newConfig(options...)
+	err := cfg.err
 	if err == nil {
-		return
+		err = runMain(cfg)
+	}
+	code, report := cfg.exitStatus(err)
+	if report {
+		cfg.reportError(err, code)
+	}
+	if code != 0 {
+		os.Exit(code)
 	}
+}
 
+// exitStatus returns the code Main exits with for err, and whether err should be reported before exiting.
+func (cfg *config) exitStatus(err error) (code int, report bool) {
 	var exit Exit
-	if errors.As(err, &exit) {
-		os.Exit(int(exit))
+	switch {
+	case err == nil:
+		return 0, false
+	case errors.As(err, &exit):
+		return int(exit), false
+	case errors.Is(err, console.ErrBrokenPipe):
+		return 0, false // our reader went away, like `mytool list | head`
 	}
-	println(`!!`, err.Error())
-	os.Exit(1)
+	return cfg.exitCode(err), true
 }
 
-func runMain(options ...Option) error {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer cancel()
-	cfg, err := New(options...)
-	if err != nil {
-		return err
+// exitCode returns the code for the first error registered with MapError that matches err, 2 for usage errors, or 1.
+func (cfg *config) exitCode(err error) int {
+	for _, it := range cfg.errorCodes {
+		if errors.Is(err, it.err) {
+			return it.code
+		}
+	}
+	var usage usageError
+	if errors.As(err, &usage) {
+		return 2
+	}
+	return 1
+}
+
+// reportError writes err to the ErrorWriter in the format specified by ErrorFormat.
+func (cfg *config) reportError(err error, code int) {
+	ctx := cfg.errorContext()
+	switch cfg.errorFormat {
+	case `json`:
+		var report struct {
+			Error string `json:"error"`
+			Task  string `json:"task,omitempty"`
+			Code  int    `json:"code"`
+		}
+		report.Error, report.Code = err.Error(), code
+		var taskErr zug.Error
+		if errors.As(err, &taskErr) {
+			report.Task = taskErr.Task
+		}
+		_ = json.NewEncoder(console.From(ctx).Stderr()).Encode(report)
+	default:
+		_ = console.PrintFailure(ctx, err.Error())
+	}
+}
+
+// errorContext returns a context with the console specified by Console options, so tees, redirects and NoColor apply
+// to errors reported by Main, with its stderr replaced by the writer specified by ErrorWriter, if any.
+func (cfg *config) errorContext() context.Context {
+	ctx := context.Background()
+	for _, with := range cfg.with {
+		ctx = with(ctx)
+	}
+	if cfg.errorWriter != nil {
+		ctx = console.With(ctx, console.Stderr(cfg.errorWriter))
 	}
+	return ctx
+}
+
+func runMain(cfg *config) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupts := make(chan os.Signal, 2)
+	signal.Notify(interrupts, os.Interrupt)
+	defer close(interrupts)
+	defer signal.Stop(interrupts)
+	go watchInterrupts(cfg.errorContext(), interrupts, cancel, os.Exit)
+	// Catching SIGPIPE keeps the runtime from killing us when stdout closes, so writes fail with EPIPE instead.
+	signal.Notify(make(chan os.Signal, 1), syscall.SIGPIPE)
 	return cfg.Run(ctx, os.Args[1:]...)
 }
 
+// watchInterrupts cancels the run on the first interrupt, then calls exit with 130 on the second interrupt in case a
+// task is not honoring its context.  The message for the second interrupt is printed to the console of ctx.  It returns
+// when interrupts is closed.
+func watchInterrupts(ctx context.Context, interrupts <-chan os.Signal, cancel func(), exit func(int)) {
+	n := 0
+	for range interrupts {
+		n++
+		if n == 1 {
+			cancel()
+			continue
+		}
+		_ = console.PrintFailure(ctx, `interrupted twice, exiting without waiting for tasks to finish`)
+		exit(130)
+		return
+	}
+}
+
 // New will assemble a configuration of tasks that can be run based on context.
 func New(options ...Option) (Interface, error) {
+	cfg := newConfig(options...)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	return cfg, nil
+}
+
+// newConfig applies options to a new configuration, stopping at the first option that sets cfg.err.
+func newConfig(options ...Option) *config {
 	cfg := &config{
 		tasks:       make([]boundTask, 0, 32),
-		defaultTask: `help`,
+		defaultArgs: []string{`help`},
 	}
 	cfg.bindTask(zug.Alias(`help`, zug.New(cfg.provideHelp)), cfg, nil, ``)
+	cfg.bindComplete()
 	for _, option := range options {
 		option.apply(cfg)
 		if cfg.err != nil {
-			return nil, cfg.err
+			break
+		}
+	}
+	cfg.hookParsers()
+	return cfg
+}
+
+// hookParsers applies the parser hooks from options like Verbosity to the parser of each task, once, adding a parser
+// for tasks that do not have one.  Aliases share the parser of their primary task.
+func (cfg *config) hookParsers() {
+	if len(cfg.parserHooks) == 0 {
+		return
+	}
+	for i := range cfg.tasks {
+		task := &cfg.tasks[i]
+		if task.alias {
+			continue
+		}
+		if task.parser == nil {
+			task.parser = parser.New() // shim in a parser.
+		}
+		for _, hook := range cfg.parserHooks {
+			hook(task.parser)
+		}
+	}
+	for i := range cfg.tasks {
+		if task := &cfg.tasks[i]; task.alias {
+			task.parser = cfg.tasks[task.primary].parser
 		}
 	}
-	return cfg, nil
+}
+
+// ErrorWriter specifies where Main writes the error that stopped it, instead of the stderr of the console specified by
+// Console options.
+func ErrorWriter(w io.Writer) Option {
+	return fnOption(func(cfg *config) { cfg.errorWriter = w })
+}
+
+// MapError causes Main to exit with the provided code, instead of 1, when a task returns an error that matches sentinel
+// using errors.Is.  Mappings are checked in the order they were added.
+func MapError(sentinel error, code int) Option {
+	return fnOption(func(cfg *config) { cfg.errorCodes = append(cfg.errorCodes, errorCode{sentinel, code}) })
+}
+
+// ErrorFormat specifies how Main reports an error before exiting.  The default, "text", prints "!!" followed by the
+// error, while "json" prints an object like {"error": "...", "task": "...", "code": 1}.
+func ErrorFormat(format string) Option {
+	return fnOption(func(cfg *config) {
+		switch format {
+		case `text`, `json`:
+			cfg.errorFormat = format
+		default:
+			cfg.err = fmt.Errorf(`unsupported error format %q`, format)
+		}
+	})
+}
+
+// Name specifies the program name used in help and by parsers, instead of the base name of the executable.
+func Name(name string) Option {
+	return fnOption(func(cfg *config) { cfg.name = name })
 }
 
 // Default specifies the default task if no arguments are provided.
 func Default(taskName string) Option {
-	return fnOption(func(cfg *config) { cfg.defaultTask = taskName })
+	return DefaultArgs(rxSpace.Split(strings.TrimSpace(taskName), -1)...)
+}
+
+// DefaultArgs specifies the arguments to use if no arguments are provided, such as "build", "all".  These are resolved
+// just like arguments from the command line, including parsing flags and applying settings.
+func DefaultArgs(args ...string) Option {
+	return fnOption(func(cfg *config) { cfg.defaultArgs = args })
+}
+
+// DefaultFromEnv specifies an environment variable, such as "ZUGZUG_DEFAULT", that overrides Default and DefaultArgs
+// if it is set to a non-empty value and no arguments are provided.  The value is split on whitespace into a command
+// and its arguments, which is convenient for container entrypoints.
+func DefaultFromEnv(name string) Option {
+	return fnOption(func(cfg *config) { cfg.defaultEnv = name })
 }
 
 // Tasks specify a set of tasks that can be run by a Zugzug configuration and can be provided as an option to New and
@@ -78,6 +248,10 @@ type Tasks []struct {
 	Use      string                      // if non-empty, explains what the task does
 	Parser   Parser                      // if non-nil this will be used to parse additional arguments and flags
 	Settings Settings                    // will be configured using the console environment
+	Validate func(context.Context) error // if non-nil, checks the parsed context before any task runs
+	Serial   bool                        // if true, never runs alongside other commands when using Parallel
+	Console  []console.Option            // if non-empty, applied to the console for this task after global options
+	Aliases  []string                    // other names for the task, which share its parser, settings and state
 }
 
 func (seq Tasks) apply(cfg *config) {
@@ -96,8 +270,89 @@ func (seq Tasks) apply(cfg *config) {
 		if name == `` {
 			panic(fmt.Errorf(`all zugzug tasks must have a name`))
 		}
-		cfg.bindTask(task, it.Parser, it.Settings, it.Use)
+		bound := cfg.bindTask(task, it.Parser, it.Settings, it.Use)
+		bound.validate = it.Validate
+		bound.fn = it.Fn
+		bound.serial = it.Serial
+		if options := it.Console; len(options) > 0 {
+			bound.with = append(bound.with, func(ctx context.Context) context.Context {
+				return console.With(ctx, options...)
+			})
+		}
+		bound.aliases = it.Aliases
+		primary := len(cfg.tasks) - 1 // bound is invalid once another task is bound.
+		for _, alias := range it.Aliases {
+			cfg.bindAlias(alias, primary)
+		}
+	}
+}
+
+// FromStruct returns a task for each exported method of v like func(context.Context) error, named after the method
+// like functions in Tasks.  The methods are bound to v, so pass a pointer if they should share state or if they have
+// pointer receivers.
+func FromStruct(v any) Tasks {
+	rv := reflect.ValueOf(v)
+	rt := rv.Type()
+	var tasks Tasks
+	for i := 0; i < rt.NumMethod(); i++ {
+		fn, ok := rv.Method(i).Interface().(func(context.Context) error)
+		if !ok {
+			continue
+		}
+		tasks = append(tasks, Tasks{{Name: sanitize(rt.Method(i).Name), Fn: fn}}...)
 	}
+	return tasks
+}
+
+// Command starts building a command with the provided name as an alternative to Tasks, which is convenient when
+// commands are assembled conditionally.  Like Tasks, if name is empty, the name of the function will be used.
+func Command(name string) CommandBuilder { return CommandBuilder{name: name} }
+
+// CommandBuilder describes a command being built by Command; Do completes it as an Option.
+type CommandBuilder struct {
+	name     string
+	use      string
+	parser   Parser
+	settings Settings
+	validate func(context.Context) error
+	serial   bool
+	console  []console.Option
+	aliases  []string
+}
+
+// Use explains what the command does.
+func (b CommandBuilder) Use(use string) CommandBuilder { b.use = use; return b }
+
+// Parser specifies a parser for additional arguments and flags.
+func (b CommandBuilder) Parser(parser Parser) CommandBuilder { b.parser = parser; return b }
+
+// Settings specifies settings that will be configured using the console environment.
+func (b CommandBuilder) Settings(settings Settings) CommandBuilder { b.settings = settings; return b }
+
+// Validate specifies a function that checks the parsed context before any task runs.
+func (b CommandBuilder) Validate(fn func(context.Context) error) CommandBuilder {
+	b.validate = fn
+	return b
+}
+
+// Serial prevents the command from running alongside other commands when using Parallel.
+func (b CommandBuilder) Serial() CommandBuilder { b.serial = true; return b }
+
+// Console specifies console options for this command, applied after global options like Verbosity.
+func (b CommandBuilder) Console(options ...console.Option) CommandBuilder {
+	b.console = append(append([]console.Option{}, b.console...), options...)
+	return b
+}
+
+// Aliases specifies other names for the command, which share its parser, settings and state.
+func (b CommandBuilder) Aliases(aliases ...string) CommandBuilder {
+	b.aliases = append(append([]string{}, b.aliases...), aliases...)
+	return b
+}
+
+// Do completes the command with the task function, returning an Option equivalent to a single entry in Tasks.
+func (b CommandBuilder) Do(fn func(context.Context) error) Option {
+	return Tasks{{Name: b.name, Fn: fn, Use: b.use, Parser: b.parser, Settings: b.settings, Validate: b.validate, Serial: b.serial, Console: b.console, Aliases: b.aliases}}
 }
 
 // Helper describes an interface that may be implemented by a parser or task to explain its arguments and flags.  This
@@ -113,12 +368,53 @@ type Helper interface {
 type Parser = parser.Interface
 
 type config struct {
-	with        []contextHook
-	parserHooks []parserHook
-	tasks       []boundTask
-	err         error
-	topics      []string
-	defaultTask string
+	with           []contextHook
+	parserHooks    []parserHook
+	before         []beforeHook
+	after          []afterHook
+	tasks          []boundTask
+	err            error
+	topics         []string
+	aliases        []string // names bound by bindAlias, which are hidden from topics
+	defaultArgs    []string
+	defaultEnv     string
+	allowPrefix    bool
+	helpLayout     Layout
+	freshState     bool
+	globals        Parser
+	errorFormat    string
+	errorCodes     []errorCode
+	name           string
+	globalSettings Settings
+	byFirstName    map[string][]int // indexes of tasks in cfg.tasks by the first word of their name
+	watch          []string         // paths to watch after running commands
+	timing         bool
+	helpToStdout   bool
+	configFiles    []string
+	settingPrefix  string
+	foldSettings   bool
+	errorWriter    io.Writer
+	globalOptions  []parser.Option
+	flags          runFlags // values of flags added by options, reset by each Run
+	keepGoing      bool
+}
+
+// runFlags holds the values of flags added by options like Verbosity and GlobalTimeout.  These are reset at the start
+// of each Run, so flags given to one Run do not apply to the next.
+type runFlags struct {
+	verbose bool
+	quiet   bool
+	silent  bool
+	yes     bool
+	timeout time.Duration
+	plan    bool
+	jobs    int // how many commands may run at once, at least 1
+	confirm bool
+}
+
+type errorCode struct {
+	err  error
+	code int
 }
 
 func (cfg *config) Parse(ctx context.Context, _ string, args []string) (context.Context, error) {
@@ -135,9 +431,39 @@ func (cfg *config) Usage(name string) string { return name + ` help [topic]` }
 
 type ctxHelpTopic struct{}
 
-func (cfg *config) Run(ctx context.Context, args ...string) error {
+// CommandName returns the name of the command selected by Run for the current task, or an empty string if the
+// context was not provided by Run.
+func CommandName(ctx context.Context) string {
+	name, _ := ctx.Value(ctxCommandName{}).(string)
+	return name
+}
+
+type ctxCommandName struct{}
+
+func (cfg *config) Run(ctx context.Context, args ...string) (err error) {
+	cfg.flags = runFlags{jobs: 1}
+	if cfg.globals != nil && len(args) > 0 && strings.HasPrefix(args[0], `-`) {
+		globalCtx, err := cfg.globals.Parse(ctx, cfg.baseCommandName(), args)
+		switch {
+		case err != nil:
+			return usageError{err}
+		case globalCtx == nil:
+			args = []string{`help`}
+		default:
+			args = parser.Args(globalCtx)
+		}
+	}
+	parentCtx := ctx
+	ctx, cancel := zug.WithCancel(ctx)
+	defer cancel()
 	if len(args) == 0 {
-		args = []string{cfg.defaultTask}
+		args = append([]string(nil), cfg.defaultArgs...)
+		if cfg.defaultEnv != `` {
+			lookup := withOverrides(ctx, cfg.foldSettings, envLookup(ctx, cfg.foldSettings))
+			if str, _ := lookup(cfg.defaultEnv); strings.TrimSpace(str) != `` {
+				args = rxSpace.Split(strings.TrimSpace(str), -1)
+			}
+		}
 	} else {
 		switch args[0] {
 		case `--help`, `-h`:
@@ -145,19 +471,47 @@ func (cfg *config) Run(ctx context.Context, args ...string) error {
 		}
 	}
 
-	type job struct {
-		ctx  context.Context
-		task zug.Task
-	}
 	var jobs []job
+	var plan []string
 
-	lookupEnv := envLookup(ctx)
+	lookupEnv, err := cfg.settingsLookup(ctx)
+	if err != nil {
+		return err
+	}
+	if err := cfg.globalSettings.Apply(lookupEnv); err != nil {
+		return err
+	}
 
 	for len(args) > 0 {
 		// TODO: support for using "--" to separate arguments from the command and its flags.
 		task := cfg.match(args...)
 		if task == nil {
-			return fmt.Errorf(`unknown command %q; try "help" for a list of commands`, strings.Join(args, ` `))
+			group := args
+			switch group[len(group)-1] {
+			case `--help`, `-h`:
+				group = group[:len(group)-1]
+			}
+			if len(cfg.subcommands(group...)) > 0 {
+				if len(jobs) > 0 {
+					name := strings.Join(group, ` `)
+					return usageError{fmt.Errorf(`%q is a group of commands; try "help %s" for a list`, name, name)}
+				}
+				cfg.explainGroup(ctx, group...)
+				return nil
+			}
+		}
+		if task == nil && cfg.allowPrefix {
+			var err error
+			task, err = cfg.matchPrefix(args...)
+			if err != nil {
+				return err
+			}
+		}
+		if task == nil {
+			if suggestion := cfg.suggest(args...); suggestion != `` {
+				return usageError{fmt.Errorf(`unknown command %q; did you mean %q?`, strings.Join(args, ` `), suggestion)}
+			}
+			return usageError{fmt.Errorf(`unknown command %q; try "help" for a list of commands`, strings.Join(args, ` `))}
 		}
 		if task.settings != nil {
 			err := task.settings.Apply(lookupEnv)
@@ -166,24 +520,23 @@ func (cfg *config) Run(ctx context.Context, args ...string) error {
 			}
 		}
 		args = args[len(task.name):]
+		step := strings.Join(task.name, ` `)
 		taskCtx := ctx
-		if task.parser == nil && len(cfg.parserHooks) > 0 {
-			task.parser = parser.New() // shim in a parser.
-		}
 		if task.parser != nil {
 			var err error
-			for _, hook := range cfg.parserHooks {
-				hook(task.parser)
-			}
 			taskCtx, err = task.parser.Parse(ctx, cfg.baseCommandName()+` `+strings.Join(task.name, ` `), args)
 			if err != nil {
-				return err
+				return usageError{err}
 			}
 			if taskCtx == nil {
 				return cfg.explainTopic(ctx, strings.Join(task.name, ` `))
 			}
+			if len(args) > 0 {
+				step += ` ` + console.FormatArgs(args...)
+			}
 			args = nil // we assume the parser has consumed all arguments
 		}
+		taskCtx = context.WithValue(taskCtx, ctxCommandName{}, strings.Join(task.name, ` `))
 		for _, with := range task.with {
 			taskCtx = with(taskCtx)
 		}
@@ -194,19 +547,254 @@ func (cfg *config) Run(ctx context.Context, args ...string) error {
 				return cfg.explainTopic(ctx, strings.Join(task.name, ` `))
 			}
 		}
-		jobs = append(jobs, job{ctx: taskCtx, task: task.task})
+		if task.validate != nil {
+			if err := task.validate(taskCtx); err != nil {
+				return usageError{fmt.Errorf(`%w; try "help %s" for usage`, err, strings.Join(task.name, ` `))}
+			}
+		}
+		jobTask := task.task
+		if cfg.freshState {
+			taskCtx = worker.With(taskCtx, worker.EmptyState())
+			if task.fn != nil {
+				jobTask = zug.Alias(task.task.TaskName(), zug.New(task.fn))
+			}
+		}
+		jobs = append(jobs, job{ctx: taskCtx, task: jobTask, fn: task.fn, serial: task.serial})
+		plan = append(plan, step)
+	}
+
+	if timeout := cfg.flags.timeout; timeout > 0 {
+		// the jobs were parsed with ctx before the timeout was known, so each one needs the deadline, too.
+		deadline := time.Now().Add(timeout)
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithDeadline(ctx, deadline)
+		defer cancelTimeout()
+		for i := range jobs {
+			var cancelJob context.CancelFunc
+			jobs[i].ctx, cancelJob = context.WithDeadline(jobs[i].ctx, deadline)
+			defer cancelJob()
+		}
+		defer func() {
+			// each job has its own timer, so a job may fail before the timer for ctx fires.
+			if err != nil && !time.Now().Before(deadline) {
+				err = fmt.Errorf(`timed out after %v: %w`, timeout, err)
+			}
+		}()
+	}
+
+	if cfg.flags.plan {
+		if err := console.Print(ctx, `PLAN:`); err != nil {
+			return err
+		}
+		for _, step := range plan {
+			if err := console.Printf(ctx, "  %s\n", step); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if cfg.flags.confirm {
+		if err := cfg.confirmPlan(ctx, jobs); err != nil {
+			return err
+		}
 	}
 
+	if len(cfg.watch) > 0 && watchable(jobs) {
+		return cfg.watchJobs(ctx, jobs)
+	}
+	return cfg.runJobs(parentCtx, ctx, jobs)
+}
+
+// runJobs runs each job in order, stopping at the first error, unless KeepGoing was specified, or when ctx is canceled.
+func (cfg *config) runJobs(parentCtx, ctx context.Context, jobs []job) error {
+	if cfg.flags.jobs > 1 {
+		return cfg.runParallel(parentCtx, ctx, jobs)
+	}
+	var timings []jobTiming
+	if cfg.timing {
+		defer func() { printTimings(ctx, timings) }()
+	}
+	var failures zug.Errors
 	for _, job := range jobs {
-		err := zug.Run(job.ctx, job.task)
-		if err != nil {
+		if ctx.Err() != nil {
+			break
+		}
+		start := time.Now()
+		err := cfg.runJob(job.ctx, job.task)
+		if cfg.timing {
+			timings = append(timings, jobTiming{CommandName(job.ctx), time.Since(start)})
+		}
+		if errors.Is(err, context.Canceled) && ctx.Err() != nil && parentCtx.Err() == nil {
+			break
+		}
+		if err == nil {
+			continue
+		}
+		if !cfg.keepGoing {
 			return err
 		}
+		failures = append(failures, job.failure(err))
+	}
+	if len(failures) > 0 {
+		return failures
 	}
+	return parentCtx.Err() // nil if a task used zug.Cancel to stop the run, which is not an error.
+}
 
+// runParallel is like runJobs, but runs up to cfg.flags.jobs jobs at once, except serial jobs, which run alone.  After a
+// failure, no more jobs are started unless KeepGoing was specified, but jobs that already started may finish.
+func (cfg *config) runParallel(parentCtx, ctx context.Context, jobs []job) error {
+	var (
+		wg       sync.WaitGroup
+		control  sync.Mutex
+		timings  []jobTiming
+		failures zug.Errors
+	)
+	stopped := func() bool {
+		control.Lock()
+		defer control.Unlock()
+		return ctx.Err() != nil || (len(failures) > 0 && !cfg.keepGoing)
+	}
+	slots := make(chan struct{}, cfg.flags.jobs)
+	for _, next := range jobs {
+		if next.serial {
+			wg.Wait()
+		}
+		if stopped() {
+			break
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(j job) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			start := time.Now()
+			err := cfg.runJob(j.ctx, j.task)
+			control.Lock()
+			defer control.Unlock()
+			if cfg.timing {
+				timings = append(timings, jobTiming{CommandName(j.ctx), time.Since(start)})
+			}
+			if err != nil && !(errors.Is(err, context.Canceled) && ctx.Err() != nil && parentCtx.Err() == nil) {
+				failures = append(failures, j.failure(err))
+			}
+		}(next)
+		if next.serial {
+			wg.Wait()
+		}
+	}
+	wg.Wait()
+	if cfg.timing {
+		printTimings(ctx, timings)
+	}
+	if len(failures) > 0 {
+		return failures
+	}
+	return parentCtx.Err() // nil if a task used zug.Cancel to stop the run, which is not an error.
+}
+
+// failure returns err as a zug.Error identifying the job, if it is not one already.
+func (j job) failure(err error) zug.Error {
+	failure, ok := err.(zug.Error)
+	if !ok {
+		failure = zug.Error{Task: CommandName(j.ctx), Err: err}
+	}
+	return failure
+}
+
+// job is a task selected by Run along with the context for running it.
+type job struct {
+	ctx    context.Context
+	task   zug.NamedTask
+	fn     func(context.Context) error // if non-nil, the function wrapped by task
+	serial bool                        // if true, the job must not run alongside other jobs
+}
+
+// confirmPlan rehearses each job with empty state and console.WithPlan to collect the commands they would run, then
+// asks the user to confirm them.  Jobs that do not have a function, like help, are not planned.
+func (cfg *config) confirmPlan(ctx context.Context, jobs []job) error {
+	var commands []string
+	for _, job := range jobs {
+		if job.fn == nil {
+			continue
+		}
+		planCtx, plan := console.WithPlan(zug.Rehearse(worker.With(job.ctx, worker.EmptyState())))
+		err := zug.Run(planCtx, job.task)
+		if err != nil {
+			return fmt.Errorf(`%w while planning`, err)
+		}
+		commands = append(commands, plan.Commands()...)
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+	for _, with := range cfg.with {
+		ctx = with(ctx) // so flags like --yes from AutoConfirm apply.
+	}
+	_ = console.PrintError(ctx, `PLAN:`)
+	for _, command := range commands {
+		_ = console.PrintError(ctx, `  >>`, command)
+	}
+	ok, err := console.Confirm(ctx, `run these commands?`)
+	switch {
+	case err != nil:
+		return err
+	case !ok:
+		return fmt.Errorf(`plan was not confirmed`)
+	}
 	return nil
 }
 
+// runJob runs a task surrounded by the Before and After hooks.  If the task is skipped, the After hooks receive
+// zug.ErrSkip, but it is treated as success once they are done.
+func (cfg *config) runJob(ctx context.Context, task zug.NamedTask) (err error) {
+	for _, before := range cfg.before {
+		var next context.Context
+		next, err = before(ctx)
+		if err != nil {
+			break
+		}
+		ctx = next
+	}
+	if err == nil {
+		var skip error
+		err = zug.Run(zug.WithSkipNote(ctx, noteSkip), recordSkip{task, &skip})
+		if err == nil {
+			err = skip // zug.Run treats a skip as success, but After hooks should see it.
+		}
+		if flushErr := console.Flush(ctx); err == nil {
+			err = flushErr
+		}
+	}
+	for i := len(cfg.after) - 1; i >= 0; i-- {
+		err = cfg.after[i](ctx, err)
+	}
+	if errors.Is(err, zug.ErrSkip) {
+		err = nil
+	}
+	return err
+}
+
+// noteSkip prints a note for a task that was skipped, whether it is the command or a task the command ran.
+func noteSkip(ctx context.Context, task string, err error) {
+	_ = console.PrintError(ctx, `--`, task+`:`, err)
+}
+
+// recordSkip wraps a task to store zug.ErrSkip in skip if the task returns it.
+type recordSkip struct {
+	zug.NamedTask
+	skip *error
+}
+
+func (t recordSkip) RunTask(ctx context.Context) error {
+	err := t.NamedTask.RunTask(ctx)
+	if errors.Is(err, zug.ErrSkip) {
+		*t.skip = err
+	}
+	return err
+}
+
 type runConfig struct {
 	ctx  context.Context
 	task *boundTask
@@ -217,24 +805,29 @@ func (cfg *config) provideHelp(ctx context.Context) error {
 		return cfg.explainTopic(ctx, topic)
 	}
 
-	argv0 := cfg.baseCommandName()
-	tw := tabwriter.NewWriter(console.From(ctx).Stderr(), 0, 0, 2, ' ', 0)
+	tw := tabwriter.NewWriter(cfg.helpOutput(ctx), 0, 0, 2, ' ', 0)
 	defer tw.Flush()
 	fmt.Fprintln(tw, `COMMANDS:`)
-	for _, topic := range cfg.topics {
-		if topic == `help` {
-			continue
-		}
-		task := cfg.matchStr(topic)
-		if task == nil {
-			continue
+	cfg.listCommands(tw, cfg.topics)
+
+	if lister, ok := cfg.globals.(FlagLister); ok {
+		if flags := lister.Flags(); len(flags) > 0 {
+			fmt.Fprintln(tw, "\nGLOBAL FLAGS:")
+			for _, flag := range flags {
+				if flag.Shorthand != `` {
+					fmt.Fprintf(tw, "  -%s, --%s \t%s\n", flag.Shorthand, flag.Name, flag.Usage)
+				} else {
+					fmt.Fprintf(tw, "  --%s \t%s\n", flag.Name, flag.Usage)
+				}
+			}
 		}
-		usage := task.use
-		if ix := strings.IndexByte(usage, '\n'); ix > 0 {
-			usage = usage[:ix]
+	}
+
+	if len(cfg.globalSettings) > 0 {
+		fmt.Fprintln(tw, "\nGLOBAL SETTINGS:")
+		for _, it := range cfg.globalSettings {
+			fmt.Fprintln(tw, settingExplanation(it.label(), it.Use, it.value()))
 		}
-		usage = strings.TrimSuffix(usage, "\r")
-		fmt.Fprintf(tw, "  %s %s \t%s\n", argv0, topic, usage)
 	}
 
 	hasSettings := false
@@ -252,11 +845,12 @@ func (cfg *config) provideHelp(ctx context.Context) error {
 	explained := make(map[string]struct{}, len(cfg.tasks))
 	for _, task := range cfg.tasks {
 		for _, it := range task.settings {
-			if _, ok := explained[it.Name]; ok {
+			label := it.label()
+			if _, ok := explained[label]; ok {
 				continue
 			}
-			explained[it.Name] = struct{}{}
-			fmt.Fprintln(tw, settingExplanation(it.Name, it.Use, get(it.Var)))
+			explained[label] = struct{}{}
+			fmt.Fprintln(tw, settingExplanation(label, it.Use, it.value()))
 		}
 	}
 	return nil
@@ -265,22 +859,33 @@ func (cfg *config) provideHelp(ctx context.Context) error {
 func (cfg *config) explainTopic(ctx context.Context, topic string) error {
 	task := cfg.matchStr(topic)
 	if task == nil {
-		return fmt.Errorf(`no help available for "%q"`, topic)
+		if cfg.explainGroup(ctx, strings.Split(topic, ` `)...) {
+			return nil
+		}
+		return usageError{fmt.Errorf(`no help available for %q; try "help" for a list of commands`, topic)}
 	}
 	argv0 := cfg.baseCommandName()
 	if helper, ok := task.parser.(Helper); ok {
-		_ = console.PrintError(ctx, helper.Help(argv0+` `+topic))
+		fmt.Fprintln(cfg.helpOutput(ctx), helper.Help(argv0+` `+topic))
 	} else if helper, ok := task.task.(Helper); ok {
-		_ = console.PrintError(ctx, helper.Help(argv0+` `+topic))
+		fmt.Fprintln(cfg.helpOutput(ctx), helper.Help(argv0+` `+topic))
 	} else {
-		_ = console.PrintError(ctx, `COMMAND:`, argv0, topic)
+		fmt.Fprintln(cfg.helpOutput(ctx), `COMMAND:`, argv0, topic)
 	}
 
-	if len(task.settings) > 0 {
-		tw := tabwriter.NewWriter(console.From(ctx).Stderr(), 0, 0, 2, ' ', 0)
-		fmt.Fprintln(tw, `SETTINGS:`)
-		for _, it := range task.settings {
-			fmt.Fprintln(tw, settingExplanation(it.Name, it.Use, get(it.Var)))
+	if len(task.settings) > 0 || len(cfg.globalSettings) > 0 {
+		tw := tabwriter.NewWriter(cfg.helpOutput(ctx), 0, 0, 2, ' ', 0)
+		if len(task.settings) > 0 {
+			fmt.Fprintln(tw, `SETTINGS:`)
+			for _, it := range task.settings {
+				fmt.Fprintln(tw, settingExplanation(it.label(), it.Use, it.value()))
+			}
+		}
+		if len(cfg.globalSettings) > 0 {
+			fmt.Fprintln(tw, `GLOBAL SETTINGS:`)
+			for _, it := range cfg.globalSettings {
+				fmt.Fprintln(tw, settingExplanation(it.label(), it.Use, it.value()))
+			}
 		}
 		_ = tw.Flush()
 	}
@@ -288,6 +893,72 @@ func (cfg *config) explainTopic(ctx context.Context, topic string) error {
 	return nil
 }
 
+// listCommands writes a line to tw for each command in topics with the first line of its usage.
+func (cfg *config) listCommands(tw io.Writer, topics []string) {
+	argv0 := cfg.baseCommandName()
+	for _, topic := range topics {
+		if topic == `help` {
+			continue
+		}
+		task := cfg.matchStr(topic)
+		if task == nil {
+			continue
+		}
+		usage := task.use
+		if ix := strings.IndexByte(usage, '\n'); ix > 0 {
+			usage = usage[:ix]
+		}
+		usage = strings.TrimSuffix(usage, "\r")
+		if len(task.aliases) > 0 {
+			usage = strings.TrimSpace(usage + ` (aliases: ` + strings.Join(task.aliases, `, `) + `)`)
+		}
+		switch cfg.helpLayout {
+		case UseFirst:
+			fmt.Fprintf(tw, "  %s \t%s %s\n", usage, argv0, topic)
+		default:
+			fmt.Fprintf(tw, "  %s %s \t%s\n", argv0, topic, usage)
+		}
+	}
+}
+
+// subcommands returns the topics for commands whose names start with the words in args but are longer, so "list"
+// returns "list go-sources" and "list go sources".
+func (cfg *config) subcommands(args ...string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	prefix := strings.Join(args, ` `)
+	var topics []string
+	for _, i := range cfg.byFirstName[args[0]] {
+		task := &cfg.tasks[i]
+		if len(task.name) > len(args) && strings.Join(task.name[:len(args)], ` `) == prefix {
+			topics = append(topics, strings.Join(task.name, ` `))
+		}
+	}
+	return topics
+}
+
+// explainGroup lists the subcommands of a command group like "list", returning false if there are none.
+func (cfg *config) explainGroup(ctx context.Context, args ...string) bool {
+	topics := cfg.subcommands(args...)
+	if len(topics) == 0 {
+		return false
+	}
+	tw := tabwriter.NewWriter(cfg.helpOutput(ctx), 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintln(tw, `COMMANDS:`)
+	cfg.listCommands(tw, topics)
+	return true
+}
+
+// helpOutput returns the console stdout if HelpToStdout was specified, or stderr otherwise.
+func (cfg *config) helpOutput(ctx context.Context) io.Writer {
+	if cfg.helpToStdout {
+		return console.From(ctx).Stdout()
+	}
+	return console.From(ctx).Stderr()
+}
+
 func settingExplanation(name, use, value string) string {
 	if value == `` {
 		return fmt.Sprintf("  %s \t%s", name, use)
@@ -297,7 +968,10 @@ func settingExplanation(name, use, value string) string {
 }
 
 func (cfg *config) baseCommandName() string {
-	argv0 := os.Args[0] // TODO: let the user override this
+	if cfg.name != `` {
+		return cfg.name
+	}
+	argv0 := os.Args[0]
 	argv0 = strings.TrimSuffix(argv0, `.exe`)
 	if ix := strings.LastIndexByte(argv0, filepath.Separator); ix >= 0 {
 		argv0 = argv0[ix+1:]
@@ -305,15 +979,25 @@ func (cfg *config) baseCommandName() string {
 	return argv0
 }
 
-func (cfg *config) bindTask(task zug.NamedTask, parser Parser, settings Settings, use string) {
+// bindTask adds a task to the configuration, returning it so the caller can fill in optional fields.
+func (cfg *config) bindTask(task zug.NamedTask, parser Parser, settings Settings, use string) *boundTask {
 	nameStr := strings.TrimSpace(task.TaskName())
 	var nameSeq []string
 	if nameStr != `` {
 		nameSeq = rxSpace.Split(nameStr, -1)
 		nameStr = strings.Join(nameSeq, ` `)
+		if cfg.isBound(nameStr) && cfg.err == nil {
+			cfg.err = fmt.Errorf(`duplicate command %q`, nameStr)
+		}
 		cfg.topics = append(cfg.topics, nameStr)
 	}
 
+	if len(nameSeq) > 0 {
+		if cfg.byFirstName == nil {
+			cfg.byFirstName = make(map[string][]int, 32)
+		}
+		cfg.byFirstName[nameSeq[0]] = append(cfg.byFirstName[nameSeq[0]], len(cfg.tasks))
+	}
 	cfg.tasks = append(cfg.tasks, boundTask{
 		with:     append([]contextHook{}, cfg.with...),
 		name:     nameSeq,
@@ -322,6 +1006,39 @@ func (cfg *config) bindTask(task zug.NamedTask, parser Parser, settings Settings
 		settings: settings,
 		use:      use,
 	})
+	return &cfg.tasks[len(cfg.tasks)-1]
+}
+
+// isBound returns true if name is a topic or an alias of a task that was already bound.
+func (cfg *config) isBound(name string) bool {
+	for _, topic := range cfg.topics {
+		if topic == name {
+			return true
+		}
+	}
+	for _, alias := range cfg.aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// bindAlias binds another name for the task at index primary in cfg.tasks, hidden from help, which shares its task,
+// parser, settings and hooks.
+func (cfg *config) bindAlias(alias string, primary int) {
+	if strings.TrimSpace(alias) == `` {
+		return
+	}
+	task := cfg.tasks[primary]
+	bound := cfg.bindTask(zug.Alias(alias, task.task), task.parser, task.settings, task.use)
+	cfg.topics = cfg.topics[:len(cfg.topics)-1] // hidden from help, which lists it with the primary.
+	name := bound.name
+	cfg.aliases = append(cfg.aliases, strings.Join(name, ` `))
+	*bound = task
+	bound.name = name
+	bound.aliases = nil
+	bound.alias, bound.primary = true, primary
 }
 
 var rxSpace = regexp.MustCompile(`\s+`)
@@ -331,9 +1048,13 @@ func (cfg *config) matchStr(name string) *boundTask {
 	return cfg.match(strings.Split(name, ` `)...)
 }
 
-// match returns the named task that matches the provided arguments, or nil if none match.
+// match returns the first named task that matches the provided arguments, or nil if none match.  Only tasks whose
+// first name matches args[0] are considered, using cfg.byFirstName.
 func (cfg *config) match(args ...string) *boundTask {
-	for i := range cfg.tasks {
+	if len(args) == 0 {
+		return nil
+	}
+	for _, i := range cfg.byFirstName[args[0]] {
 		task := &cfg.tasks[i]
 		if task.matches(args...) {
 			return task
@@ -342,6 +1063,93 @@ func (cfg *config) match(args ...string) *boundTask {
 	return nil
 }
 
+// matchPrefix returns the only task whose first name starts with args[0] and whose remaining names match exactly, nil
+// if there is none, or an error if there are several.
+func (cfg *config) matchPrefix(args ...string) (*boundTask, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	var found []*boundTask
+	seen := make(map[int]struct{})
+	for i := range cfg.tasks {
+		task := &cfg.tasks[i]
+		if len(task.name) == 0 || !strings.HasPrefix(task.name[0], args[0]) {
+			continue
+		}
+		if !task.matches(append([]string{task.name[0]}, args[1:]...)...) {
+			continue
+		}
+		primary := i
+		if task.alias {
+			primary = task.primary
+		}
+		if _, ok := seen[primary]; ok {
+			continue // an alias of a task that was already found is not ambiguous.
+		}
+		seen[primary] = struct{}{}
+		found = append(found, task)
+	}
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		return found[0], nil
+	}
+	names := make([]string, len(found))
+	for i, task := range found {
+		names[i] = strconv.Quote(strings.Join(task.name, ` `))
+	}
+	return nil, usageError{fmt.Errorf(`ambiguous command %q could be %s`, args[0], strings.Join(names, `, `))}
+}
+
+// suggest returns the topic closest to the provided arguments, or an empty string if none are close enough to be a
+// plausible typo.
+func (cfg *config) suggest(args ...string) string {
+	best, bestDistance := ``, 3 // more than two edits is not a typo.
+	for _, topic := range cfg.topics {
+		n := strings.Count(topic, ` `) + 1
+		if n > len(args) {
+			n = len(args)
+		}
+		distance := editDistance(strings.Join(args[:n], ` `), topic)
+		if distance < bestDistance && distance*2 <= len(topic) {
+			best, bestDistance = topic, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b, in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	next := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ra {
+		next[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			next[j+1] = minInt(prev[j+1]+1, next[j]+1, prev[j]+cost)
+		}
+		prev, next = next, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(first int, rest ...int) int {
+	for _, v := range rest {
+		if v < first {
+			first = v
+		}
+	}
+	return first
+}
+
 type boundTask struct {
 	with     []contextHook
 	name     []string
@@ -349,6 +1157,12 @@ type boundTask struct {
 	use      string
 	parser   Parser
 	settings Settings
+	validate func(context.Context) error
+	fn       func(context.Context) error // if non-nil, the function wrapped by task
+	serial   bool
+	aliases  []string // other names bound for this task, listed in help
+	alias    bool     // if true, this is another name for the task at index primary in cfg.tasks
+	primary  int
 }
 
 // matches returns true if the args[:len(task.name)] matches task.name.
@@ -368,10 +1182,94 @@ func (t *boundTask) matches(args ...string) bool {
 type Interface interface {
 	// Run will select a task based on the provided arguments.  If that task specifies a parser, its parser will be
 	// provided with any remaining arguments.  Otherwise, Run will use the next argument to select another task, and
-	// so on.
+	// so on.  Flags are parsed into state shared by the Interface, so Run must not be called concurrently.
 	Run(ctx context.Context, args ...string) error
 }
 
+// AllowPrefixMatch lets the first word of a command be abbreviated to any unique prefix, such as "che" for "check".
+// Exact matches always take precedence, and a prefix that matches more than one command is an error.
+func AllowPrefixMatch() Option {
+	return fnOption(func(cfg *config) { cfg.allowPrefix = true })
+}
+
+// GlobalSettings specifies settings that are configured using the console environment before any command runs.  Help
+// explains them separately from the settings for specific commands, which are only configured when their command is
+// selected.
+func GlobalSettings(settings Settings) Option {
+	return fnOption(func(cfg *config) { cfg.globalSettings = append(cfg.globalSettings, settings...) })
+}
+
+// Globals specifies flags that may precede the command, like "--output file.txt build".  If no command follows them,
+// the default is run.  Flags from repeated uses of Globals are combined.
+func Globals(options ...parser.Option) Option {
+	return fnOption(func(cfg *config) {
+		cfg.globalOptions = append(cfg.globalOptions, options...)
+		cfg.globals = parser.Leading(cfg.globalOptions...)
+	})
+}
+
+// GlobalTimeout adds a "--timeout" flag for tasks whose parsers support parser.Flagger, which limits how long the
+// commands selected by the command line may run, including any time spent confirming them.  When the timeout expires,
+// the context given to tasks reports context.DeadlineExceeded, and if the run fails, Main reports that it timed out.
+func GlobalTimeout() Option {
+	return fnOption(func(cfg *config) {
+		cfg.parserHooks = append(cfg.parserHooks, func(fs parser.Interface) {
+			if f, ok := fs.(parser.Flagger); ok {
+				f.Flag(parser.Duration(&cfg.flags.timeout, `timeout`, ``, `cancels the commands if they run longer than this`))
+			}
+		})
+	})
+}
+
+// Parallel adds a "-j / --jobs" global flag that runs up to that many of the commands selected by the command line at
+// once, like "make -j".  Commands marked Serial still run alone.  By default, commands run one at a time.
+func Parallel() Option {
+	return fnOption(func(cfg *config) {
+		cfg.flags.jobs = 1
+		Globals(parser.Int(&cfg.flags.jobs, `jobs`, `j`, `runs up to this many commands at once`)).apply(cfg)
+	})
+}
+
+// ShowPlan adds a "--plan" global flag that prints the commands selected by the command line, with their arguments, in
+// the order they would run, then exits without running them.  Unlike console.DryRun, tasks are not run at all.
+func ShowPlan() Option {
+	return fnOption(func(cfg *config) {
+		Globals(parser.Bool(&cfg.flags.plan, `plan`, ``, `prints the commands that would run, without running them`)).apply(cfg)
+	})
+}
+
+// KeepGoing runs every command selected by the command line even if some fail, like "make -k".  If any fail, Run
+// returns their errors as zug.Errors.  By default, Run stops at the first command that fails.
+func KeepGoing() Option {
+	return fnOption(func(cfg *config) { cfg.keepGoing = true })
+}
+
+// FreshState runs each command selected by the command line with empty state, so naming a command twice, as in
+// "build build", runs it twice along with its dependencies.  By default, commands share state, so each task and
+// dependency runs at most once per invocation.
+func FreshState() Option {
+	return fnOption(func(cfg *config) { cfg.freshState = true })
+}
+
+// HelpToStdout writes help to stdout instead of stderr, so it can be piped to a pager.  Errors, including usage
+// errors, are still written to stderr.
+func HelpToStdout() Option {
+	return fnOption(func(cfg *config) { cfg.helpToStdout = true })
+}
+
+// HelpLayout specifies the order of columns when help lists commands.
+func HelpLayout(layout Layout) Option {
+	return fnOption(func(cfg *config) { cfg.helpLayout = layout })
+}
+
+// Layout describes the order of columns when help lists commands.
+type Layout int
+
+const (
+	CommandFirst Layout = iota // lists each command followed by its use, the default.
+	UseFirst                   // lists the use of each command followed by the command.
+)
+
 // Console specifies console options.
 func Console(options ...console.Option) Option {
 	return fnOption(func(cfg *config) {
@@ -381,31 +1279,73 @@ func Console(options ...console.Option) Option {
 	})
 }
 
+// Before adds a hook that is run before each task selected by the command line, in the order they were added.  The
+// hook may return a new context for the task, or an error that prevents the task from running.
+func Before(hook func(ctx context.Context) (context.Context, error)) Option {
+	return fnOption(func(cfg *config) { cfg.before = append(cfg.before, hook) })
+}
+
+// After adds a hook that is run after each task selected by the command line, in the reverse of the order they were
+// added.  The hook receives the error from the task (or a Before hook) and returns the error that will be reported.  If
+// the task was skipped, the hook receives an error wrapping zug.ErrSkip, which is treated as success if the hooks
+// return it.
+func After(hook func(ctx context.Context, err error) error) Option {
+	return fnOption(func(cfg *config) { cfg.after = append(cfg.after, hook) })
+}
+
+// ConfirmPlan adds a "--confirm" flag for tasks whose parsers support parser.BoolFlagger.  When it is set, each
+// selected task is first rehearsed with console.WithPlan to collect the commands it would run, which are printed before
+// asking the user to confirm them.  Tasks made by zug.New, including those from console.Do, still run for real after a
+// rehearsal, as described by zug.Rehearse.  The Go code of each task does run during the rehearsal, so tasks should
+// avoid side effects other than commands if they can be planned this way.
+func ConfirmPlan() Option {
+	return fnOption(func(cfg *config) {
+		cfg.parserHooks = append(cfg.parserHooks, func(fs parser.Interface) {
+			if bf, ok := fs.(parser.BoolFlagger); ok {
+				bf.BoolFlag(&cfg.flags.confirm, `confirm`, ``, `shows the commands that will run and asks before running them`)
+			}
+		})
+	})
+}
+
+// AutoConfirm adds a "-y / --yes" flag to tasks whose parsers support parser.BoolFlagger, which causes
+// console.Confirm to answer yes without asking.
+func AutoConfirm() Option {
+	return fnOption(func(cfg *config) {
+		cfg.parserHooks = append(cfg.parserHooks, func(fs parser.Interface) {
+			if bf, ok := fs.(parser.BoolFlagger); ok {
+				bf.BoolFlag(&cfg.flags.yes, `yes`, `y`, `answers yes instead of asking for confirmation`)
+			}
+		})
+		cfg.with = append(cfg.with, func(ctx context.Context) context.Context {
+			if cfg.flags.yes {
+				ctx = console.With(ctx, console.AssumeYes())
+			}
+			return ctx
+		})
+	})
+}
+
 // Verbosity specifies control of console verbosity for tasks whose parsers support parser.BoolFlagger.  This adds flags for
 // "-v / --verbose", "-q / --quiet", and "-s / --silent".
 func Verbosity() Option {
 	return fnOption(func(cfg *config) {
-		var (
-			quiet   = false
-			verbose = false
-			silent  = false
-		)
 		cfg.parserHooks = append(cfg.parserHooks, func(fs parser.Interface) {
 			bf, ok := fs.(parser.BoolFlagger)
 			if !ok {
 				return
 			}
-			bf.BoolFlag(&verbose, `verbose`, `v`, `logs commands their stderr to stderr`)
-			bf.BoolFlag(&quiet, `quiet`, `q`, `only logs commands and their stderr to stderr if they fail`)
-			bf.BoolFlag(&silent, `silent`, `s`, `suppresses command logging entirely`)
+			bf.BoolFlag(&cfg.flags.verbose, `verbose`, `v`, `logs commands their stderr to stderr`)
+			bf.BoolFlag(&cfg.flags.quiet, `quiet`, `q`, `only logs commands and their stderr to stderr if they fail`)
+			bf.BoolFlag(&cfg.flags.silent, `silent`, `s`, `suppresses command logging entirely`)
 		})
 		cfg.with = append(cfg.with, func(ctx context.Context) context.Context {
 			switch {
-			case verbose:
+			case cfg.flags.verbose:
 				ctx = console.With(ctx, console.Verbose())
-			case quiet:
+			case cfg.flags.quiet:
 				ctx = console.With(ctx, console.Quiet())
-			case silent:
+			case cfg.flags.silent:
 				ctx = console.With(ctx, console.Silent())
 			}
 			return ctx
@@ -413,6 +1353,15 @@ func Verbosity() Option {
 	})
 }
 
+// Apply applies one or more options as an option.
+func Apply(options ...Option) Option {
+	return fnOption(func(cfg *config) {
+		for _, option := range options {
+			option.apply(cfg)
+		}
+	})
+}
+
 type fnOption func(*config)
 
 func (fn fnOption) apply(cfg *config) { fn(cfg) }
@@ -445,6 +1394,16 @@ type Exit int
 
 func (ex Exit) Error() string { return fmt.Sprint(`exit code `, int(ex)) }
 
+// usageError indicates the command line was not acceptable, causing Main to exit with code 2.
+type usageError struct{ err error }
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
 type parserHook func(parser.Interface)
 
+type beforeHook func(context.Context) (context.Context, error)
+
+type afterHook func(context.Context, error) error
+
 type contextHook func(context.Context) context.Context
@@ -0,0 +1,991 @@
+// Copyright (c) 2023, Scott W. Dunlop
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package zugzug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swdunlop/zugzug-go/zug"
+	"github.com/swdunlop/zugzug-go/zug/console"
+	"github.com/swdunlop/zugzug-go/zug/console/consoletest"
+	"github.com/swdunlop/zugzug-go/zug/parser"
+	"github.com/swdunlop/zugzug-go/zug/worker"
+)
+
+// mustNew is like New, but fails the test if the configuration is invalid.
+func mustNew(t *testing.T, options ...Option) Interface {
+	t.Helper()
+	z, err := New(append([]Option{Name(`test`)}, options...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return z
+}
+
+func TestConfirmPlan(t *testing.T) {
+	for _, tc := range []struct {
+		answer  string
+		wantErr bool
+	}{
+		{"y\n", false},
+		{"n\n", true},
+	} {
+		t.Run(strings.TrimSpace(tc.answer), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), `created`)
+			dep := console.Do(`touch`, path) // package-level style dependency, made by zug.New.
+			z := mustNew(t, ConfirmPlan(), Tasks{{Name: `create`, Fn: func(ctx context.Context) error {
+				return zug.Run(ctx, dep)
+			}}})
+			ctx, _, stderr := consoletest.New(consoletest.Stdin(tc.answer))
+			err := z.Run(ctx, `create`, `--confirm`)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf(`got error %v, want error %v`, err, tc.wantErr)
+			}
+			if !strings.Contains(stderr.String(), `>> touch `) {
+				t.Errorf(`plan did not list the command: %q`, stderr.String())
+			}
+			_, statErr := os.Stat(path)
+			if created := statErr == nil; created == tc.wantErr {
+				t.Errorf(`created is %v after answering %q`, created, tc.answer)
+			}
+		})
+	}
+}
+
+func TestAliases(t *testing.T) {
+	runs := 0
+	z := mustNew(t, AllowPrefixMatch(), FreshState(), Tasks{{
+		Name:    `build`,
+		Aliases: []string{`bld`},
+		Fn:      func(ctx context.Context) error { runs++; return nil },
+	}})
+	ctx, _, _ := consoletest.New()
+	for _, args := range [][]string{{`build`}, {`bld`}, {`b`}} {
+		if err := z.Run(ctx, args...); err != nil {
+			t.Fatalf(`%q: %v`, args, err)
+		}
+	}
+	if runs != 3 {
+		t.Errorf(`build ran %v times, want 3`, runs)
+	}
+
+	ctx, _, stderr := consoletest.New()
+	if err := z.Run(ctx, `help`); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr.String(), `(aliases: bld)`) {
+		t.Errorf(`help does not list the alias: %q`, stderr.String())
+	}
+	if strings.Contains(stderr.String(), `test bld`) {
+		t.Errorf(`help lists the alias as a command: %q`, stderr.String())
+	}
+}
+
+// listTasks returns tasks named "build", "list go" and "list go-sources" that append their name to ran.
+func listTasks(ran *[]string) Tasks {
+	task := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error { *ran = append(*ran, name); return nil }
+	}
+	return Tasks{
+		{Name: `build`, Fn: task(`build`), Use: `builds it`},
+		{Name: `list go`, Fn: task(`list go`), Use: `lists go packages`},
+		{Name: `list go-sources`, Fn: task(`list go-sources`), Use: `lists go sources`},
+	}
+}
+
+func TestGroups(t *testing.T) {
+	var ran []string
+	z := mustNew(t, listTasks(&ran))
+	for _, args := range [][]string{{`list`}, {`list`, `--help`}, {`help`, `list`}} {
+		ctx, _, stderr := consoletest.New()
+		if err := z.Run(ctx, args...); err != nil {
+			t.Fatalf(`%q: %v`, args, err)
+		}
+		for _, child := range []string{`test list go `, `test list go-sources `} {
+			if !strings.Contains(stderr.String(), child) {
+				t.Errorf(`%q does not list %q: %q`, args, child, stderr.String())
+			}
+		}
+		if strings.Contains(stderr.String(), `build`) {
+			t.Errorf(`%q lists commands outside the group: %q`, args, stderr.String())
+		}
+	}
+
+	ctx, _, _ := consoletest.New()
+	err := z.Run(ctx, `build`, `list`)
+	if err == nil || !strings.Contains(err.Error(), `"list" is a group of commands`) {
+		t.Errorf(`got %v for a group after a command`, err)
+	}
+	if len(ran) > 0 {
+		t.Errorf(`ran %q, but the command line was not valid`, ran)
+	}
+}
+
+func TestReportError(t *testing.T) {
+	var stderr, override strings.Builder
+	failure := zug.Error{Task: `build`, Err: errors.New(`failed`)}
+
+	cfg := newConfig(Console(console.Stderr(&stderr), console.Color(false)))
+	cfg.reportError(failure, 1)
+	if !strings.Contains(stderr.String(), `!! `) || !strings.Contains(stderr.String(), `failed`) {
+		t.Errorf(`error did not reach the console stderr: %q`, stderr.String())
+	}
+
+	stderr.Reset()
+	cfg = newConfig(Console(console.Stderr(&stderr)), ErrorWriter(&override), ErrorFormat(`json`))
+	cfg.reportError(failure, 3)
+	if stderr.Len() > 0 {
+		t.Errorf(`error reached the console stderr despite ErrorWriter: %q`, stderr.String())
+	}
+	if got := override.String(); !strings.Contains(got, `"task":"build"`) || !strings.Contains(got, `"code":3`) {
+		t.Errorf(`got %q from ErrorWriter`, got)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want map[string]any
+	}{
+		{[]string{`bogus`}, map[string]any{
+			`error`: `unknown command "bogus"; try "help" for a list of commands`,
+			`code`:  2.0,
+		}},
+		{[]string{`fail`}, map[string]any{`error`: `fail: it broke`, `task`: `fail`, `code`: 1.0}},
+	} {
+		var output strings.Builder
+		cfg := newConfig(Name(`test`), ErrorFormat(`json`), ErrorWriter(&output), Tasks{{
+			Name: `fail`,
+			Fn:   func(ctx context.Context) error { return errors.New(`it broke`) },
+		}})
+		ctx, _, _ := consoletest.New()
+		err := cfg.Run(ctx, tc.args...)
+		code, report := cfg.exitStatus(err)
+		if !report {
+			t.Fatalf(`%q: %v would not be reported`, tc.args, err)
+		}
+		cfg.reportError(err, code)
+		var got map[string]any
+		if err := json.Unmarshal([]byte(output.String()), &got); err != nil {
+			t.Fatalf(`%q: %v in %q`, tc.args, err, output.String())
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf(`%q: got %v, want %v`, tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestSkip(t *testing.T) {
+	var ran []string
+	var seen []error
+	z := mustNew(t,
+		After(func(ctx context.Context, err error) error {
+			seen = append(seen, err)
+			return err
+		}),
+		Tasks{
+			{Name: `generate`, Fn: func(ctx context.Context) error {
+				ran = append(ran, `generate`)
+				return fmt.Errorf(`%w: up to date`, zug.ErrSkip)
+			}},
+			{Name: `build`, Fn: func(ctx context.Context) error {
+				ran = append(ran, `build`)
+				return nil
+			}},
+		},
+	)
+	ctx, _, stderr := consoletest.New()
+	if err := z.Run(ctx, `generate`, `build`); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 2 {
+		t.Errorf(`ran %q, want build to run after the skip`, ran)
+	}
+	if len(seen) != 2 || !errors.Is(seen[0], zug.ErrSkip) || seen[1] != nil {
+		t.Errorf(`After saw %v, want the skip and then success`, seen)
+	}
+	if !strings.Contains(stderr.String(), `-- generate: skipped: up to date`) {
+		t.Errorf(`skip was not noted: %q`, stderr.String())
+	}
+	dir := t.TempDir()
+	for _, name := range []string{`main.go`, `main`} { // the output is written last, so it is up to date.
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	generate := console.FileTask(`generate-main`, []string{`main`}, []string{`main.go`}, func(ctx context.Context) error {
+		ran = append(ran, `generate-main`)
+		return nil
+	})
+	z = mustNew(t, Tasks{{Name: `install`, Fn: func(ctx context.Context) error { return zug.Run(ctx, generate) }}})
+	ran = nil
+	ctx, _, stderr = consoletest.New(console.Dir(dir))
+	if err := z.Run(ctx, `install`); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) > 0 {
+		t.Errorf(`ran %q, but the outputs are up to date`, ran)
+	}
+	if !strings.Contains(stderr.String(), `-- generate-main: skipped, outputs are up to date`) {
+		t.Errorf(`skip of a dependency was not noted: %q`, stderr.String())
+	}
+}
+
+func TestWatchInterrupts(t *testing.T) {
+	ctx, _, stderr := consoletest.New()
+	interrupts := make(chan os.Signal)
+	canceled := make(chan struct{})
+	exits := make(chan int, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watchInterrupts(ctx, interrupts, func() { close(canceled) }, func(code int) { exits <- code })
+	}()
+
+	interrupts <- os.Interrupt
+	<-canceled
+	select {
+	case code := <-exits:
+		t.Fatalf(`exited with %v after the first interrupt`, code)
+	default:
+	}
+
+	interrupts <- os.Interrupt
+	if code := <-exits; code != 130 {
+		t.Errorf(`exited with %v, want 130`, code)
+	}
+	<-done
+	if !strings.Contains(stderr.String(), `interrupted twice`) {
+		t.Errorf(`did not explain the forced exit: %q`, stderr.String())
+	}
+
+	interrupts = make(chan os.Signal)
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		watchInterrupts(ctx, interrupts, func() {}, func(code int) { t.Errorf(`exited with %v`, code) })
+	}()
+	close(interrupts)
+	<-done // returns when the signal loop is stopped.
+}
+
+func TestExitStatus(t *testing.T) {
+	errMapped, errNotFound := errors.New(`mapped`), errors.New(`not found`)
+	cfg := newConfig(MapError(errMapped, 3), MapError(errNotFound, 44))
+	for _, tc := range []struct {
+		err    error
+		code   int
+		report bool
+	}{
+		{nil, 0, false},
+		{fmt.Errorf(`%w while listing`, console.ErrBrokenPipe), 0, false},
+		{Exit(4), 4, false},
+		{errors.New(`failed`), 1, true},
+		{fmt.Errorf(`%w in build`, errMapped), 3, true},
+		{zug.Error{Task: `fetch`, Err: errNotFound}, 44, true},
+		{usageError{errors.New(`bad flag`)}, 2, true},
+	} {
+		code, report := cfg.exitStatus(tc.err)
+		if code != tc.code || report != tc.report {
+			t.Errorf(`%v: got %v, %v, want %v, %v`, tc.err, code, report, tc.code, tc.report)
+		}
+	}
+}
+
+func TestHooks(t *testing.T) {
+	var calls []string
+	before := func(name string, err error) Option {
+		return Before(func(ctx context.Context) (context.Context, error) {
+			calls = append(calls, `before `+name)
+			return context.WithValue(ctx, ctxTestHook{}, name), err
+		})
+	}
+	after := func(name string) Option {
+		return After(func(ctx context.Context, err error) error {
+			calls = append(calls, `after `+name)
+			if err != nil {
+				return fmt.Errorf(`%w (seen by %v)`, err, name)
+			}
+			return nil
+		})
+	}
+	task := Tasks{{Name: `build`, Fn: func(ctx context.Context) error {
+		calls = append(calls, `build with `+ctx.Value(ctxTestHook{}).(string))
+		return nil
+	}}}
+
+	z := mustNew(t, before(`a`, nil), before(`b`, nil), after(`a`), after(`b`), task)
+	ctx, _, _ := consoletest.New()
+	if err := z.Run(ctx, `build`); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`before a`, `before b`, `build with b`, `after b`, `after a`}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf(`got calls %q, want %q`, calls, want)
+	}
+
+	calls = nil
+	errBefore := errors.New(`not ready`)
+	z = mustNew(t, before(`a`, errBefore), before(`b`, nil), after(`a`), task)
+	err := z.Run(ctx, `build`)
+	if !errors.Is(err, errBefore) || !strings.Contains(err.Error(), `(seen by a)`) {
+		t.Errorf(`got %v, want the Before error as seen by the After hook`, err)
+	}
+	if want := []string{`before a`, `after a`}; !reflect.DeepEqual(calls, want) {
+		t.Errorf(`got calls %q, want %q`, calls, want)
+	}
+}
+
+type ctxTestHook struct{}
+
+func TestCommandBuilder(t *testing.T) {
+	fn := func(ctx context.Context) error { return nil }
+	port := 0
+	p := parser.New(parser.Bool(new(bool), `open`, `o`, `opens a browser`))
+	settings := Settings{{Var: &port, Name: `PORT`}}
+	for _, tc := range []struct {
+		name    string
+		builder Option
+		tasks   Tasks
+	}{
+		{
+			`full`,
+			Command(`serve`).Use(`serves it`).Parser(p).Settings(settings).Serial().Aliases(`s`).Do(fn),
+			Tasks{{Name: `serve`, Fn: fn, Use: `serves it`, Parser: p, Settings: settings, Serial: true, Aliases: []string{`s`}}},
+		},
+		{`defaults`, Command(`serve`).Do(fn), Tasks{{Name: `serve`, Fn: fn}}},
+		{`unnamed`, Command(``).Do(testTask), Tasks{{Fn: testTask}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			built, listed := newConfig(tc.builder), newConfig(tc.tasks)
+			if built.err != nil || listed.err != nil {
+				t.Fatal(built.err, listed.err)
+			}
+			if !reflect.DeepEqual(built.topics, listed.topics) {
+				t.Errorf(`got topics %q, want %q`, built.topics, listed.topics)
+			}
+			if len(built.tasks) != len(listed.tasks) {
+				t.Fatalf(`got %v tasks, want %v`, len(built.tasks), len(listed.tasks))
+			}
+			for i := range built.tasks {
+				b, l := built.tasks[i], listed.tasks[i]
+				if b.parser == Parser(built) && l.parser == Parser(listed) {
+					continue // help, which parses using its configuration.
+				}
+				b.fn, l.fn, b.task, l.task = nil, nil, nil, nil // functions cannot be compared.
+				if !reflect.DeepEqual(b, l) {
+					t.Errorf("got task %+v\nwant %+v", b, l)
+				}
+			}
+		})
+	}
+}
+
+func testTask(ctx context.Context) error { return nil }
+
+func TestValidate(t *testing.T) {
+	var start, end int
+	ran := false
+	z := mustNew(t, Tasks{{
+		Name: `range`,
+		Fn:   func(ctx context.Context) error { ran = true; return nil },
+		Parser: parser.New(
+			parser.Int(&start, `start`, ``, `first value`),
+			parser.Int(&end, `end`, ``, `last value`),
+		),
+		Validate: func(ctx context.Context) error {
+			if start > end {
+				return fmt.Errorf(`start %v is after end %v`, start, end)
+			}
+			return nil
+		},
+	}})
+	ctx, _, _ := consoletest.New()
+	if err := z.Run(ctx, `range`, `--start`, `1`, `--end`, `2`); err != nil || !ran {
+		t.Fatalf(`got %v and ran %v for a valid range`, err, ran)
+	}
+
+	ran = false
+	err := z.Run(ctx, `range`, `--start`, `3`, `--end`, `2`)
+	var usage usageError
+	if !errors.As(err, &usage) || !strings.Contains(err.Error(), `start 3 is after end 2; try "help range"`) {
+		t.Errorf(`got %v, want a usage error from validation`, err)
+	}
+	if ran {
+		t.Error(`ran despite failing validation`)
+	}
+}
+
+func TestDuplicateTasks(t *testing.T) {
+	fn := func(ctx context.Context) error { return nil }
+	for _, tasks := range []Tasks{
+		{{Name: `build`, Fn: fn}, {Name: `build`, Fn: fn}},
+		{{Name: `list  go`, Fn: fn}, {Name: `list go`, Fn: fn}},
+		{{Name: `help`, Fn: fn}},
+	} {
+		_, err := New(tasks)
+		if err == nil || !strings.Contains(err.Error(), `duplicate command`) {
+			t.Errorf(`got %v for %v and %v, want a duplicate command error`, err, tasks[0].Name, tasks[len(tasks)-1].Name)
+		}
+	}
+}
+
+func TestPrefixMatch(t *testing.T) {
+	var ran []string
+	task := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error { ran = append(ran, name); return nil }
+	}
+	z := mustNew(t, AllowPrefixMatch(), FreshState(), Tasks{
+		{Name: `check`, Fn: task(`check`)},
+		{Name: `checkout`, Fn: task(`checkout`)},
+		{Name: `clean`, Fn: task(`clean`)},
+		{Name: `deploy prod`, Fn: task(`deploy prod`)},
+	})
+	ctx, _, _ := consoletest.New()
+	for _, tc := range []struct {
+		arg, want string
+	}{
+		{`cl`, `clean`},
+		{`check`, `check`}, // exact, although it is also a prefix of checkout.
+		{`checko`, `checkout`},
+	} {
+		ran = nil
+		if err := z.Run(ctx, tc.arg); err != nil {
+			t.Fatalf(`%v: %v`, tc.arg, err)
+		}
+		if len(ran) != 1 || ran[0] != tc.want {
+			t.Errorf(`%v ran %q, want %v`, tc.arg, ran, tc.want)
+		}
+	}
+
+	ran = nil
+	if err := z.Run(ctx, `dep`, `prod`); err != nil || len(ran) != 1 || ran[0] != `deploy prod` {
+		t.Errorf(`dep prod: got %v and ran %q`, err, ran)
+	}
+
+	ran = nil
+	err := z.Run(ctx, `c`)
+	if err == nil || !strings.Contains(err.Error(), `ambiguous`) {
+		t.Errorf(`got %v for an ambiguous prefix`, err)
+	}
+	if len(ran) > 0 {
+		t.Errorf(`ran %q for an ambiguous prefix`, ran)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	fn := func(ctx context.Context) error { return nil }
+	z := mustNew(t, Tasks{{Name: `check`, Fn: fn}, {Name: `build`, Fn: fn}})
+	ctx, _, _ := consoletest.New()
+	for _, tc := range []struct{ arg, want string }{
+		{`chekc`, `unknown command "chekc"; did you mean "check"?`},
+		{`xyzzy-plugh`, `unknown command "xyzzy-plugh"; try "help" for a list of commands`},
+	} {
+		err := z.Run(ctx, tc.arg)
+		if err == nil || err.Error() != tc.want {
+			t.Errorf(`got %v, want %v`, err, tc.want)
+		}
+	}
+}
+
+func TestHelpTopicError(t *testing.T) {
+	var ran []string
+	z := mustNew(t, listTasks(&ran))
+	ctx, _, _ := consoletest.New()
+	err := z.Run(ctx, `help`, `bogus`)
+	if err == nil {
+		t.Fatal(`help for an unknown topic did not fail`)
+	}
+	msg := err.Error()
+	if want := `no help available for "bogus"; try "help" for a list of commands`; !strings.HasSuffix(msg, want) {
+		t.Errorf(`got %q, want it to end with %q`, msg, want)
+	}
+	if strings.Contains(msg, `""`) {
+		t.Errorf(`%q quotes the topic twice`, msg)
+	}
+}
+
+func TestDefaultArgs(t *testing.T) {
+	var ran []string
+	target := ``
+	z := mustNew(t, DefaultArgs(`build`, `all`, `--target`, `linux`), Tasks{
+		{Name: `build one`, Fn: func(ctx context.Context) error { ran = append(ran, `build one`); return nil }},
+		{
+			Name:   `build all`,
+			Fn:     func(ctx context.Context) error { ran = append(ran, `build all for `+target); return nil },
+			Parser: parser.New(parser.String(&target, `target`, ``, `target platform`)),
+		},
+	})
+	ctx, _, _ := consoletest.New()
+	if err := z.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`build all for linux`}; !reflect.DeepEqual(ran, want) {
+		t.Errorf(`ran %q, want %q`, ran, want)
+	}
+}
+
+func TestDefaultFromEnv(t *testing.T) {
+	for _, tc := range []struct {
+		env  []string
+		want []string
+	}{
+		{nil, []string{`build`}},
+		{[]string{`ZUGZUG_DEFAULT=`}, []string{`build`}},
+		{[]string{`ZUGZUG_DEFAULT=list go`}, []string{`list go`}},
+		{[]string{"ZUGZUG_DEFAULT=  build \t list  go-sources "}, []string{`build`, `list go-sources`}},
+	} {
+		var ran []string
+		z := mustNew(t, Default(`build`), DefaultFromEnv(`ZUGZUG_DEFAULT`), listTasks(&ran))
+		ctx, _, _ := consoletest.New(console.FullEnv(tc.env))
+		if err := z.Run(ctx); err != nil {
+			t.Fatalf(`%q: %v`, tc.env, err)
+		}
+		if !reflect.DeepEqual(ran, tc.want) {
+			t.Errorf(`%q: ran %q, want %q`, tc.env, ran, tc.want)
+		}
+	}
+}
+
+func TestShowPlan(t *testing.T) {
+	var ran []string
+	target := ``
+	tasks := append(listTasks(&ran), Tasks{{
+		Name:   `deploy`,
+		Fn:     func(ctx context.Context) error { ran = append(ran, `deploy to `+target); return nil },
+		Parser: parser.New(parser.String(&target, `target`, ``, `target host`)),
+	}}...)
+	z := mustNew(t, ShowPlan(), tasks)
+	ctx, stdout, _ := consoletest.New()
+	if err := z.Run(ctx, `--plan`, `list`, `go-sources`, `build`, `deploy`, `--target`, `prod host`); err != nil {
+		t.Fatal(err)
+	}
+	if want := "PLAN:\n  list go-sources\n  build\n  deploy --target 'prod host'\n"; stdout.String() != want {
+		t.Errorf(`got %q, want %q`, stdout.String(), want)
+	}
+	if len(ran) > 0 {
+		t.Errorf(`ran %q while planning`, ran)
+	}
+}
+
+func TestGlobalValueWithoutCommand(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{`--output`, `file.txt`}, `build to file.txt`},
+		{[]string{`--output=file.txt`}, `build to file.txt`},
+		{[]string{`--output`, `file.txt`, `clean`}, `clean file.txt`},
+	} {
+		var ran []string
+		output := ``
+		z := mustNew(t, Default(`build`), Globals(parser.String(&output, `output`, ``, `output file`)), Tasks{
+			{Name: `build`, Fn: func(ctx context.Context) error { ran = append(ran, `build to `+output); return nil }},
+			{Name: `clean`, Fn: func(ctx context.Context) error { ran = append(ran, `clean `+output); return nil }},
+		})
+		ctx, _, _ := consoletest.New()
+		if err := z.Run(ctx, tc.args...); err != nil {
+			t.Fatalf(`%q: %v`, tc.args, err)
+		}
+		if want := []string{tc.want}; !reflect.DeepEqual(ran, want) {
+			t.Errorf(`%q ran %q, want %q`, tc.args, ran, want)
+		}
+	}
+}
+
+func TestHelpToStdout(t *testing.T) {
+	for _, args := range [][]string{{`help`}, {`help`, `build`}, {`build`, `--help`}} {
+		var ran []string
+		z := mustNew(t, HelpToStdout(), listTasks(&ran))
+		ctx, stdout, stderr := consoletest.New()
+		if err := z.Run(ctx, args...); err != nil {
+			t.Fatalf(`%q: %v`, args, err)
+		}
+		if !strings.Contains(stdout.String(), `test build`) || stderr.Len() > 0 {
+			t.Errorf("%q wrote %q to stdout and %q to stderr, want help on stdout", args, stdout.String(), stderr.String())
+		}
+	}
+
+	var ran []string
+	z := mustNew(t, HelpToStdout(), listTasks(&ran))
+	ctx, stdout, _ := consoletest.New()
+	if err := z.Run(ctx, `bogus`); err == nil {
+		t.Error(`an unknown command did not fail`)
+	}
+	if stdout.Len() > 0 {
+		t.Errorf(`a usage error wrote %q to stdout`, stdout.String())
+	}
+}
+
+func TestGlobalTimeout(t *testing.T) {
+	var seen error
+	z := mustNew(t, GlobalTimeout(), Tasks{{
+		Name:   `sleep`,
+		Parser: parser.New(),
+		Fn: func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				seen = ctx.Err()
+				return seen
+			case <-time.After(10 * time.Second):
+				return nil
+			}
+		},
+	}})
+	ctx, _, _ := consoletest.New()
+	started := time.Now()
+	err := z.Run(ctx, `sleep`, `--timeout`, `50ms`)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf(`took %v, the timeout did not cut off the task`, elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), `timed out after 50ms`) {
+		t.Errorf(`got %v, want a timeout error`, err)
+	}
+	if !errors.Is(seen, context.DeadlineExceeded) {
+		t.Errorf(`the task saw %v, want its deadline exceeded`, seen)
+	}
+
+	z = mustNew(t, GlobalTimeout(), Tasks{{
+		Name:   `slow`,
+		Parser: parser.New(),
+		Fn: func(ctx context.Context) error {
+			time.Sleep(100 * time.Millisecond) // ignores ctx, and succeeds after the deadline.
+			return nil
+		},
+	}})
+	if err := z.Run(ctx, `slow`, `--timeout`, `50ms`); err != nil {
+		t.Errorf(`got %v for a command that succeeded after the deadline`, err)
+	}
+}
+
+func TestKeepGoing(t *testing.T) {
+	for _, keepGoing := range []bool{false, true} {
+		var ran []string
+		task := func(name string, err error) func(context.Context) error {
+			return func(ctx context.Context) error { ran = append(ran, name); return err }
+		}
+		options := []Option{Tasks{
+			{Name: `lint`, Fn: task(`lint`, nil)},
+			{Name: `test`, Fn: task(`test`, errors.New(`tests failed`))},
+			{Name: `build`, Fn: task(`build`, nil)},
+		}}
+		want := []string{`lint`, `test`}
+		if keepGoing {
+			options = append(options, KeepGoing())
+			want = append(want, `build`)
+		}
+		z := mustNew(t, options...)
+		ctx, _, _ := consoletest.New()
+		err := z.Run(ctx, `lint`, `test`, `build`)
+		if err == nil || !strings.Contains(err.Error(), `tests failed`) {
+			t.Errorf(`keepGoing=%v: got %v, want the test failure`, keepGoing, err)
+		}
+		var errs zug.Errors
+		if keepGoing && (!errors.As(err, &errs) || len(errs) != 1) {
+			t.Errorf(`got %#v, want zug.Errors with one error`, err)
+		}
+		if !reflect.DeepEqual(ran, want) {
+			t.Errorf(`keepGoing=%v: ran %q, want %q`, keepGoing, ran, want)
+		}
+	}
+}
+
+func TestParallel(t *testing.T) {
+	for _, tc := range []struct {
+		args   []string
+		serial bool
+		want   int32
+	}{
+		{[]string{`a`, `b`, `c`}, false, 1},
+		{[]string{`-j`, `2`, `a`, `b`, `c`}, false, 2},
+		{[]string{`--jobs=3`, `a`, `b`, `c`}, false, 3},
+		{[]string{`-j`, `3`, `a`, `b`, `c`}, true, 1},
+	} {
+		var active, most int32
+		task := func(ctx context.Context) error {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				prev := atomic.LoadInt32(&most)
+				if n <= prev || atomic.CompareAndSwapInt32(&most, prev, n) {
+					break
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}
+		z := mustNew(t, Parallel(), Tasks{
+			{Name: `a`, Fn: task},
+			{Name: `b`, Fn: task, Serial: tc.serial},
+			{Name: `c`, Fn: task},
+		})
+		ctx, _, _ := consoletest.New()
+		if err := z.Run(ctx, tc.args...); err != nil {
+			t.Fatalf(`%q: %v`, tc.args, err)
+		}
+		if most != tc.want {
+			t.Errorf(`%q with serial %v: ran %v at once, want %v`, tc.args, tc.serial, most, tc.want)
+		}
+	}
+}
+
+// unreadable is a stdin that fails the test if it is read.
+type unreadable struct{ t *testing.T }
+
+func (r unreadable) Read(p []byte) (int, error) {
+	r.t.Error(`stdin was read`)
+	return 0, io.EOF
+}
+
+func TestAutoConfirm(t *testing.T) {
+	for _, args := range [][]string{{`wipe`, `--yes`}, {`wipe`, `-y`}, {`wipe`}} {
+		var confirmed bool
+		z := mustNew(t, AutoConfirm(), Tasks{{
+			Name:   `wipe`,
+			Parser: parser.New(),
+			Fn: func(ctx context.Context) (err error) {
+				confirmed, err = console.Confirm(ctx, `wipe everything?`)
+				return err
+			},
+		}})
+		stdin := console.Stdin(unreadable{t})
+		if len(args) == 1 {
+			stdin = consoletest.Stdin("n\n")
+		}
+		ctx, _, stderr := consoletest.New(stdin)
+		if err := z.Run(ctx, args...); err != nil {
+			t.Fatalf(`%q: %v`, args, err)
+		}
+		if want := len(args) > 1; confirmed != want {
+			t.Errorf(`%q: confirmed is %v, want %v`, args, confirmed, want)
+		}
+		if asked := strings.Contains(stderr.String(), `wipe everything?`); asked != (len(args) == 1) {
+			t.Errorf(`%q: asked is %v: %q`, args, asked, stderr.String())
+		}
+	}
+}
+
+func TestTaskConsole(t *testing.T) {
+	quiet := map[string]bool{}
+	task := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			quiet[name] = console.From(ctx).IsQuiet()
+			return console.Run(ctx, `echo`, name)
+		}
+	}
+	z := mustNew(t, Console(console.Verbose()), Tasks{
+		{Name: `noisy`, Fn: task(`noisy`), Console: []console.Option{console.Quiet()}},
+		{Name: `chatty`, Fn: task(`chatty`)},
+	})
+	ctx, stdout, stderr := consoletest.New()
+	if err := z.Run(ctx, `noisy`, `chatty`); err != nil {
+		t.Fatal(err)
+	}
+	if !quiet[`noisy`] || quiet[`chatty`] {
+		t.Errorf(`got quiet %v, want only noisy to be quiet`, quiet)
+	}
+	if strings.Contains(stderr.String(), `>> echo noisy`) || !strings.Contains(stderr.String(), `>> echo chatty`) {
+		t.Errorf(`got %q, want only chatty to echo its command`, stderr.String())
+	}
+	if stdout.String() != "noisy\nchatty\n" {
+		t.Errorf(`got %q, want the output of both commands`, stdout.String())
+	}
+}
+
+func TestHelpLayout(t *testing.T) {
+	for _, tc := range []struct {
+		layout Layout
+		want   string
+	}{
+		{CommandFirst, `test build builds it`},
+		{UseFirst, `builds it test build`},
+	} {
+		var ran []string
+		z := mustNew(t, HelpLayout(tc.layout), listTasks(&ran))
+		ctx, _, stderr := consoletest.New()
+		if err := z.Run(ctx, `help`); err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, line := range strings.Split(stderr.String(), "\n") {
+			found = found || strings.Join(strings.Fields(line), ` `) == tc.want // ignoring the width of columns.
+		}
+		if !found {
+			t.Errorf("layout %v does not contain %q:\n%s", tc.layout, tc.want, stderr.String())
+		}
+	}
+}
+
+func TestFreshState(t *testing.T) {
+	for _, tc := range []struct {
+		options []Option
+		want    int
+	}{
+		{nil, 1},
+		{[]Option{FreshState()}, 2},
+	} {
+		runs, deps := 0, 0
+		dep := func(ctx context.Context) error { deps++; return nil } // tracked by worker state, unlike zug.New.
+		z := mustNew(t, append(tc.options, Tasks{{Name: `build`, Fn: func(ctx context.Context) error {
+			runs++
+			return zug.Run(ctx, dep)
+		}}})...)
+		ctx, _, _ := consoletest.New()
+		ctx = worker.With(ctx, worker.EmptyState()) // so earlier runs of this test do not count.
+		if err := z.Run(ctx, `build`, `build`); err != nil {
+			t.Fatal(err)
+		}
+		if runs != tc.want {
+			t.Errorf(`build ran %v times with %v options, want %v`, runs, len(tc.options), tc.want)
+		}
+		if deps != tc.want {
+			t.Errorf(`its dependency ran %v times with %v options, want %v`, deps, len(tc.options), tc.want)
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	var ran []string
+	z := mustNew(t, Tasks{
+		{Name: `stop`, Fn: func(ctx context.Context) error { ran = append(ran, `stop`); zug.Cancel(ctx); return nil }},
+		{Name: `next`, Fn: func(ctx context.Context) error { ran = append(ran, `next`); return nil }},
+	})
+	ctx, _, _ := consoletest.New()
+	_ = z.Run(ctx, `stop`, `next`)
+	if want := []string{`stop`}; !reflect.DeepEqual(ran, want) {
+		t.Errorf(`ran %q, want %q`, ran, want)
+	}
+}
+
+func TestShortName(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{`help`}, `gt clean`},
+		{[]string{`help`, `build`}, `gt build`},
+		{[]string{`help`, `clean`}, `gt clean`},
+		{[]string{`build`, `--help`}, `gt build`},
+	} {
+		target := ``
+		z := mustNew(t, Name(`gt`), Tasks{
+			{Name: `build`, Fn: testTask, Use: `builds it`,
+				Parser: parser.New(parser.String(&target, `target`, ``, `target platform`))},
+			{Name: `clean`, Fn: testTask, Use: `cleans up`},
+		})
+		ctx, _, stderr := consoletest.New()
+		if err := z.Run(ctx, tc.args...); err != nil {
+			t.Fatalf(`%q: %v`, tc.args, err)
+		}
+		if !strings.Contains(stderr.String(), tc.want) {
+			t.Errorf("%q does not mention %q:\n%s", tc.args, tc.want, stderr.String())
+		}
+		if strings.Contains(stderr.String(), `test `) || strings.Contains(stderr.String(), `zugzug`) {
+			t.Errorf("%q uses another name:\n%s", tc.args, stderr.String())
+		}
+	}
+}
+
+// linearMatch is how match worked before the byFirstName index: the first task, in order, that matches args.
+func linearMatch(cfg *config, args ...string) *boundTask {
+	if len(args) == 0 {
+		return nil
+	}
+	for i := range cfg.tasks {
+		if cfg.tasks[i].matches(args...) {
+			return &cfg.tasks[i]
+		}
+	}
+	return nil
+}
+
+// manyTasks returns n tasks with two-word names, like "cmd7 build", plus tasks whose names overlap.
+func manyTasks(n int) Tasks {
+	tasks := Tasks{
+		{Name: `build`, Fn: testTask},
+		{Name: `build all`, Fn: testTask}, // shadowed by build, which was registered first.
+		{Name: `deploy prod`, Fn: testTask},
+		{Name: `deploy`, Fn: testTask, Aliases: []string{`ship`}},
+	}
+	for i := 0; i < n; i++ {
+		tasks = append(tasks, Tasks{{Name: fmt.Sprintf(`cmd%d build`, i), Fn: testTask}}...)
+	}
+	return tasks
+}
+
+func TestMatchIndex(t *testing.T) {
+	cfg := newConfig(manyTasks(100))
+	if cfg.err != nil {
+		t.Fatal(cfg.err)
+	}
+	for _, args := range [][]string{
+		{}, {`build`}, {`build`, `all`}, {`deploy`}, {`deploy`, `prod`}, {`deploy`, `prod`, `--now`},
+		{`ship`}, {`cmd0`, `build`}, {`cmd99`, `build`, `x`}, {`cmd99`}, {`cmd100`, `build`}, {`help`}, {`bogus`},
+	} {
+		if got, want := cfg.match(args...), linearMatch(cfg, args...); got != want {
+			t.Errorf(`%q matched %v, want %v`, args, got, want)
+		}
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	cfg := newConfig(manyTasks(1000))
+	args := []string{`cmd999`, `build`, `--flag`}
+	b.Run(`index`, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cfg.match(args...)
+		}
+	})
+	b.Run(`linear`, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			linearMatch(cfg, args...)
+		}
+	})
+}
+
+// buildTools has methods that FromStruct turns into commands, sharing its state.
+type buildTools struct{ ran []string }
+
+func (b *buildTools) Compile(ctx context.Context) error { b.ran = append(b.ran, `compile`); return nil }
+
+func (b *buildTools) RunTests(ctx context.Context) error {
+	b.ran = append(b.ran, `run tests`)
+	return nil
+}
+
+func (b *buildTools) Describe() string { return `not a task` }
+
+func TestFromStruct(t *testing.T) {
+	tools := new(buildTools)
+	tasks := FromStruct(tools)
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	if want := []string{`compile`, `run-tests`}; !reflect.DeepEqual(names, want) {
+		t.Fatalf(`got commands %q, want %q`, names, want)
+	}
+	z := mustNew(t, tasks)
+	ctx, _, _ := consoletest.New()
+	if err := z.Run(ctx, `compile`, `run-tests`); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`compile`, `run tests`}; !reflect.DeepEqual(tools.ran, want) {
+		t.Errorf(`ran %q, want %q`, tools.ran, want)
+	}
+}